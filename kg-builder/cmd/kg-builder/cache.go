@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/llm"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newCacheCommand returns "kg-builder cache", whose subcommands operate on LLM_CACHE_DIR.
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the LLM cache",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show entry counts, disk usage and cumulative hit rates",
+		Args:  cobra.NoArgs,
+		RunE: withCacheDir(func(cfg *config.Config, root string, args []string) error {
+			return cacheStats(root, cfg.LLM.CacheMaxSize)
+		}),
+	})
+
+	var listFilter llm.CacheFilter
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List cache entries",
+		Args:  cobra.NoArgs,
+		RunE: withCacheDir(func(cfg *config.Config, root string, args []string) error {
+			return cacheList(root, listFilter)
+		}),
+	}
+	addCacheFilterFlags(list, &listFilter)
+	cmd.AddCommand(list)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show KEY",
+		Short: `Show the cached answer(s) for a key, e.g. "Artificial Intelligence" or "A -> B"`,
+		Args:  cobra.ExactArgs(1),
+		RunE: withCacheDir(func(cfg *config.Config, root string, args []string) error {
+			return cacheShow(root, args[0])
+		}),
+	})
+
+	var deleteFilter llm.CacheFilter
+	var dryRun bool
+	del := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete the entries matching the filters",
+		Args:  cobra.NoArgs,
+		RunE: withCacheDir(func(cfg *config.Config, root string, args []string) error {
+			if deleteFilter == (llm.CacheFilter{}) {
+				return fmt.Errorf("refusing to delete the whole cache without a filter (use --match '*' to force)")
+			}
+			return cacheDelete(root, deleteFilter, dryRun)
+		}),
+	}
+	addCacheFilterFlags(del, &deleteFilter)
+	del.Flags().BoolVar(&dryRun, "dry-run", false, "Only print what would be deleted")
+	cmd.AddCommand(del)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "vacuum",
+		Short: "Remove expired and corrupt entries, temporary files and empty directories",
+		Args:  cobra.NoArgs,
+		RunE: withCacheDir(func(cfg *config.Config, root string, args []string) error {
+			return cacheVacuum(root, cfg.LLM.CacheTTL, cfg.LLM.CacheNegativeTTL)
+		}),
+	})
+
+	var maxSize int
+	purge := &cobra.Command{
+		Use:   "purge",
+		Short: "Remove every entry, or the oldest ones until the cache fits in --max-size",
+		Args:  cobra.NoArgs,
+		RunE: withCacheDir(func(cfg *config.Config, root string, args []string) error {
+			return cachePurge(root, maxSize)
+		}),
+	}
+	purge.Flags().IntVar(&maxSize, "max-size", 0,
+		"Size in MB to bring the cache down to by removing the oldest entries (0 removes every entry)")
+	cmd.AddCommand(purge)
+	return cmd
+}
+
+// withCacheDir is withConfig for the cache subcommands, which are given LLM_CACHE_DIR. Other cache backends are
+// rejected rather than an unrelated directory being inspected.
+func withCacheDir(run func(cfg *config.Config, root string, args []string) error) func(*cobra.Command, []string) error {
+	return withConfig(func(cfg *config.Config, args []string) error {
+		// The memory cache lives in the builder's process and Redis expires and evicts entries itself
+		if cfg.LLM.CacheBackend != "file" {
+			return fmt.Errorf("kg-builder cache manages the file cache in LLM_CACHE_DIR, but LLM_CACHE_BACKEND is %s",
+				cfg.LLM.CacheBackend)
+		}
+		return run(cfg, cfg.LLM.CacheDir, args)
+	})
+}
+
+// addCacheFilterFlags registers the filter flags shared by list and delete.
+func addCacheFilterFlags(cmd *cobra.Command, filter *llm.CacheFilter) {
+	cmd.Flags().StringVar(&filter.Kind, "kind", "", "Entry kind (related concepts or mined relationships: related or rel)")
+	cmd.Flags().StringVar(&filter.Model, "model", "", "Model that produced the entry")
+	cmd.Flags().StringVar(&filter.Namespace, "namespace", "", "Cache namespace")
+	cmd.Flags().StringVar(&filter.Pattern, "match", "", `Glob pattern on the key, e.g. "Machine*"`)
+	cmd.Flags().BoolVar(&filter.Negative, "negative", false, `Only negative ("no relationship") entries`)
+}
+
+func cacheStats(root string, maxSize int64) error {
+	summary, err := llm.SummarizeCache(root)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cache directory:  %s\n", root)
+	fmt.Printf("Entries:          %d (%d negative)\n", summary.Entries, summary.Negative)
+	fmt.Printf("Size:             %.1f KiB\n", float64(summary.Size)/1024)
+	if maxSize > 0 {
+		fmt.Printf("Size limit:       %.1f KiB (LLM_CACHE_MAX_SIZE_MB)\n", float64(maxSize)/1024)
+	}
+	if summary.Entries > 0 {
+		fmt.Printf("Oldest entry:     %s\n", summary.OldestStored.Format(time.RFC3339))
+		fmt.Printf("Newest entry:     %s\n", summary.NewestStored.Format(time.RFC3339))
+	}
+	fmt.Printf("Lookups:          %s\n", summary.Lookups)
+	fmt.Printf("Hit rate:         %.2f\n", summary.Lookups.HitRate())
+
+	fmt.Println("\nEntries by kind:")
+	printCounts(summary.ByKind)
+	fmt.Println("\nEntries by partition (provider/model/prompt-version/namespace):")
+	printCounts(summary.ByPartition)
+	return nil
+}
+
+func cacheList(root string, filter llm.CacheFilter) error {
+	entries, err := llm.ListCacheEntries(root, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STORED\tKIND\tMODEL\tNAMESPACE\tNEGATIVE\tKEY")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", e.StoredAt.Format(time.RFC3339), e.Kind, e.Model, e.Namespace, e.Negative, e.Key)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d entries\n", len(entries))
+	return nil
+}
+
+func cacheShow(root, key string) error {
+	entries, err := llm.ListCacheEntries(root, llm.CacheFilter{})
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	for _, e := range entries {
+		if e.Key != key && e.Path != key {
+			continue
+		}
+		found++
+		fmt.Printf("File:      %s\n", e.Path)
+		fmt.Printf("Kind:      %s\n", e.Kind)
+		fmt.Printf("Partition: %s/%s/%s/%s\n", e.Provider, e.Model, e.PromptVersion, e.Namespace)
+		fmt.Printf("Stored:    %s\n", e.StoredAt.Format(time.RFC3339))
+		if e.RunID != "" {
+			fmt.Printf("Run:       %s\n", e.RunID)
+		}
+		fmt.Printf("Negative:  %t\n", e.Negative)
+		for _, c := range e.Concepts {
+			fmt.Printf("  %s -[%s]-> %s\n", c.RelatedTo, c.Relation, c.Name)
+		}
+		fmt.Println()
+	}
+	if found == 0 {
+		return fmt.Errorf("no cache entry found for %q", key)
+	}
+	return nil
+}
+
+func cacheDelete(root string, filter llm.CacheFilter, dryRun bool) error {
+	if dryRun {
+		entries, err := llm.ListCacheEntries(root, filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Printf("would delete %s (%s)\n", e.Key, e.Path)
+		}
+		fmt.Printf("%d entries would be deleted\n", len(entries))
+		return nil
+	}
+
+	deleted, err := llm.DeleteCacheEntries(root, filter)
+	fmt.Printf("Deleted %d entries\n", deleted)
+	return err
+}
+
+func cacheVacuum(root string, ttl, negativeTTL time.Duration) error {
+	result, err := llm.VacuumCache(root, ttl, negativeTTL)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d expired entries, %d corrupt entries, %d temporary files and %d empty directories (%.1f KiB freed)\n",
+		result.Expired, result.Corrupt, result.TempFiles, result.EmptyDirs, float64(result.FreedBytes)/1024)
+	return nil
+}
+
+func cachePurge(root string, maxSize int) error {
+	if maxSize < 0 {
+		return fmt.Errorf("--max-size must not be negative")
+	}
+
+	result, err := llm.EvictCache(root, int64(maxSize)<<20)
+	fmt.Printf("Removed %d entries (%.1f KiB freed, %.1f KiB left)\n",
+		result.Entries, float64(result.FreedBytes)/1024, float64(result.RemainingBytes)/1024)
+	return err
+}
+
+func printCounts(counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %-50s %d\n", k, counts[k])
+	}
+}
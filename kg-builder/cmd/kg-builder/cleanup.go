@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cleanupOptions are the flags of "kg-builder cleanup".
+type cleanupOptions struct {
+	orphans        bool
+	removalsBefore string
+	dryRun         bool
+}
+
+// newCleanupCommand returns "kg-builder cleanup", which deletes the concepts left without relationships, e.g. by
+// imports or curation, and optionally the old records of relationships removed by curation.
+func newCleanupCommand() *cobra.Command {
+	var opts cleanupOptions
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete concepts without relationships and old removed relationship records",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runCleanupCommand(opts)
+		}),
+	}
+	cmd.Flags().BoolVar(&opts.orphans, "orphans", true, "Delete the concepts without relationships")
+	cmd.Flags().StringVar(&opts.removalsBefore, "removals-before", "",
+		"Delete the records of relationships removed by curation before this time, as 2006-01-02 or RFC 3339")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only report what would be deleted")
+	return cmd
+}
+
+func runCleanupCommand(opts cleanupOptions) error {
+	var removalsBefore time.Time
+	if opts.removalsBefore != "" {
+		var err error
+		if removalsBefore, err = parseTime(opts.removalsBefore); err != nil {
+			return fmt.Errorf("invalid --removals-before: %w", err)
+		}
+	}
+	if !opts.orphans && removalsBefore.IsZero() {
+		return fmt.Errorf("nothing to clean up with --orphans=false and no --removals-before")
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	if opts.dryRun {
+		counts, err := neo4j.CountCleanup(driver, opts.orphans, removalsBefore)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Would delete %d concepts without relationships and %d removed relationship records\n",
+			counts.Orphans, counts.Removals)
+		return nil
+	}
+
+	counts, err := neo4j.Cleanup(driver, opts.orphans, removalsBefore)
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted %d concepts without relationships and %d removed relationship records", counts.Orphans,
+		counts.Removals)
+	return nil
+}
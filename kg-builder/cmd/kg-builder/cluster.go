@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/cluster"
+	"kg-builder/internal/config"
+	"kg-builder/internal/llm"
+	"kg-builder/internal/neo4j"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// clusterOptions are the flags of "kg-builder cluster".
+type clusterOptions struct {
+	k      int
+	top    int
+	seed   int64
+	asJSON bool
+}
+
+// newClusterCommand returns "kg-builder cluster", which embeds the concept names with the embedding model and groups
+// them with k-means, a thematic complement to the relationships of the graph.
+func newClusterCommand() *cobra.Command {
+	var opts clusterOptions
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Group the concepts into themes by the similarity of their embeddings",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runClusterCommand(cfg, opts)
+		}),
+	}
+	cmd.Flags().IntVar(&opts.k, "k", 0, "Number of clusters (default the square root of half the number of concepts)")
+	cmd.Flags().IntVar(&opts.top, "top", 5, "Number of representative concepts shown per cluster")
+	cmd.Flags().Int64Var(&opts.seed, "seed", 1, "Seed of the clustering; the same seed yields the same clusters")
+	cmd.Flags().BoolVar(&opts.asJSON, "json", false, "Print the clusters as JSON, with all their concepts")
+	return cmd
+}
+
+func runClusterCommand(cfg *config.Config, opts clusterOptions) error {
+	if opts.k < 0 || opts.top < 1 {
+		return fmt.Errorf("--k must not be negative and --top must be positive")
+	}
+	if cfg.LLM.Provider == "synthetic" {
+		return fmt.Errorf("clustering needs the embeddings of an Ollama model; the synthetic provider has none")
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	names, err := neo4j.GetConceptNames(driver)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("the graph has no concepts")
+	}
+
+	client, err := llm.NewClient(cfg.LLM)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM client: %w", err)
+	}
+	log.Printf("Embedding %d concepts with %s", len(names), cfg.LLM.EmbeddingModel)
+	vectors, err := client.Embed(context.Background(), names)
+	if err != nil {
+		return fmt.Errorf("failed to embed the concepts: %w", err)
+	}
+
+	k := opts.k
+	if k == 0 {
+		k = max(1, int(math.Round(math.Sqrt(float64(len(names))/2))))
+	}
+	clusters := cluster.KMeans(names, vectors, k, opts.top, opts.seed)
+	if opts.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(clusters)
+	}
+	printClusters(os.Stdout, clusters)
+	return nil
+}
+
+func printClusters(out io.Writer, clusters []cluster.Cluster) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSIZE\tREPRESENTATIVES")
+	for _, c := range clusters {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", c.Label, c.Size, strings.Join(c.Representatives, ", "))
+	}
+	w.Flush()
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/llm"
+	"kg-builder/internal/neo4j"
+	"kg-builder/internal/similarity"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// newMergeCommand returns "kg-builder merge --into CANONICAL DUPLICATE...".
+func newMergeCommand() *cobra.Command {
+	var into string
+	cmd := &cobra.Command{
+		Use:   "merge --into CANONICAL DUPLICATE...",
+		Short: "Merge duplicate concepts",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runMergeCommand(into, args)
+		}),
+	}
+	cmd.Flags().StringVar(&into, "into", "", "Canonical concept the duplicates are merged into")
+	cmd.MarkFlagRequired("into")
+	return cmd
+}
+
+func runMergeCommand(into string, duplicates []string) error {
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	merged, err := neo4j.MergeConcepts(driver, into, duplicates)
+	if err != nil {
+		return err
+	}
+	if merged == 0 {
+		return fmt.Errorf("none of the duplicates exist")
+	}
+	log.Printf("Merged %d concepts into %s", merged, into)
+	return nil
+}
+
+// assignmentFlag collects repeated --assign NEIGHBOR=TARGET flags.
+type assignmentFlag map[string]string
+
+func (a assignmentFlag) String() string {
+	return fmt.Sprint(map[string]string(a))
+}
+
+func (a assignmentFlag) Set(value string) error {
+	neighbor, target, ok := strings.Cut(value, "=")
+	if !ok || neighbor == "" || target == "" {
+		return fmt.Errorf("expected NEIGHBOR=TARGET, got %q", value)
+	}
+	a[neighbor] = target
+	return nil
+}
+
+func (a assignmentFlag) Type() string {
+	return "NEIGHBOR=TARGET"
+}
+
+// splitOptions are the flags of "kg-builder split".
+type splitOptions struct {
+	into        string
+	assignments assignmentFlag
+	suggest     bool
+	dryRun      bool
+}
+
+// newSplitCommand returns "kg-builder split --into A,B [--assign NEIGHBOR=TARGET]... CONCEPT". Every neighbor of the
+// concept must be assigned to one of the targets, either explicitly or, with --suggest, by the LLM.
+func newSplitCommand() *cobra.Command {
+	opts := splitOptions{assignments: assignmentFlag{}}
+	cmd := &cobra.Command{
+		Use:   "split --into A,B [--assign NEIGHBOR=TARGET]... CONCEPT",
+		Short: "Split an over-broad concept",
+		Args:  cobra.ExactArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runSplitCommand(cfg, args[0], opts)
+		}),
+	}
+	cmd.Flags().StringVar(&opts.into, "into", "", "Comma-separated concepts to split into")
+	cmd.Flags().Var(opts.assignments, "assign", "Assign the relationships with NEIGHBOR to TARGET (repeatable)")
+	cmd.Flags().BoolVar(&opts.suggest, "suggest", false, "Ask the LLM to assign the neighbors that were not assigned explicitly")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only print the assignment")
+	cmd.MarkFlagRequired("into")
+	return cmd
+}
+
+func runSplitCommand(cfg *config.Config, concept string, opts splitOptions) error {
+	targets := splitList(opts.into)
+	if len(targets) < 2 {
+		return fmt.Errorf("--into needs at least two concepts, got %q", opts.into)
+	}
+	assignments := opts.assignments
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	neighbors, err := neo4j.GetRelatedConceptNames(driver, concept)
+	if err != nil {
+		return fmt.Errorf("failed to get the neighbors of %s: %w", concept, err)
+	}
+	if len(neighbors) == 0 {
+		return fmt.Errorf("%s does not exist or has no relationships", concept)
+	}
+	sort.Strings(neighbors)
+
+	var unassigned []string
+	for _, n := range neighbors {
+		if _, ok := assignments[n]; !ok {
+			unassigned = append(unassigned, n)
+		}
+	}
+	if opts.suggest && len(unassigned) > 0 {
+		llmClient, err := llm.NewClient(cfg.LLM)
+		if err != nil {
+			return fmt.Errorf("failed to create LLM client: %w", err)
+		}
+		suggested, err := llmClient.SuggestSplit(concept, targets, unassigned)
+		if err != nil {
+			return fmt.Errorf("failed to get suggested assignment: %w", err)
+		}
+		unassigned = unassigned[:0]
+		for _, n := range neighbors {
+			if _, ok := assignments[n]; ok {
+				continue
+			}
+			if target, ok := suggested[n]; ok {
+				assignments[n] = target
+			} else {
+				unassigned = append(unassigned, n)
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NEIGHBOR\tTARGET")
+	for _, n := range neighbors {
+		if target, ok := assignments[n]; ok {
+			fmt.Fprintf(w, "%s\t%s\n", n, target)
+		}
+	}
+	w.Flush()
+
+	if len(unassigned) > 0 {
+		return fmt.Errorf("unassigned neighbors: %s", strings.Join(unassigned, ", "))
+	}
+	if opts.dryRun {
+		return nil
+	}
+
+	if err := neo4j.SplitConcept(driver, concept, targets, assignments); err != nil {
+		return err
+	}
+	log.Printf("Split %s into %s", concept, strings.Join(targets, ", "))
+	return nil
+}
+
+// splitList splits a comma-separated list, trimming spaces and dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// duplicatesOptions are the flags of "kg-builder duplicates".
+type duplicatesOptions struct {
+	threshold  float64
+	limit      int
+	embeddings bool
+}
+
+// newDuplicatesCommand returns "kg-builder duplicates", which reports likely duplicate concept pairs.
+func newDuplicatesCommand() *cobra.Command {
+	var opts duplicatesOptions
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Report likely duplicate concepts",
+		Args:  cobra.NoArgs,
+	}
+	// The defaults of --threshold and --embeddings come from the configuration, which is only loaded when the command runs
+	cmd.RunE = withConfig(func(cfg *config.Config, args []string) error {
+		if !cmd.Flags().Changed("threshold") {
+			opts.threshold = cfg.Graph.DiversityThreshold
+		}
+		if !cmd.Flags().Changed("embeddings") {
+			opts.embeddings = cfg.LLM.Provider != "synthetic"
+		}
+		return runDuplicatesCommand(cfg, opts)
+	})
+	cmd.Flags().Float64Var(&opts.threshold, "threshold", 0,
+		"Minimum similarity score of a reported pair, 0..1 (default GRAPH_DIVERSITY_THRESHOLD)")
+	cmd.Flags().IntVar(&opts.limit, "limit", 0, "Report at most this many pairs (0 for all)")
+	cmd.Flags().BoolVar(&opts.embeddings, "embeddings", false,
+		"Also compare the embeddings of the names with LLM_EMBEDDING_MODEL (default true unless LLM_PROVIDER is synthetic)")
+	return cmd
+}
+
+func runDuplicatesCommand(cfg *config.Config, opts duplicatesOptions) error {
+	if opts.threshold < 0 || opts.threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1, got %v", opts.threshold)
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	names, err := neo4j.GetConceptNames(driver)
+	if err != nil {
+		return fmt.Errorf("failed to get concept names: %w", err)
+	}
+
+	var vectors [][]float64
+	if opts.embeddings {
+		client, err := llm.NewClient(cfg.LLM)
+		if err != nil {
+			return fmt.Errorf("failed to create LLM client: %w", err)
+		}
+		log.Printf("Embedding %d concepts with %s", len(names), cfg.LLM.EmbeddingModel)
+		if vectors, err = client.Embed(context.Background(), names); err != nil {
+			return fmt.Errorf("failed to embed the concepts (use --embeddings=false to compare names only): %w", err)
+		}
+	}
+
+	pairs := similarity.Duplicates(names, vectors, opts.threshold)
+	if opts.limit > 0 && len(pairs) > opts.limit {
+		pairs = pairs[:opts.limit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tLEVENSHTEIN\tTOKENS\tEMBEDDING\tCONCEPT\tDUPLICATE")
+	for _, p := range pairs {
+		embedding := "-"
+		if p.Embedding != nil {
+			embedding = fmt.Sprintf("%.2f", *p.Embedding)
+		}
+		fmt.Fprintf(w, "%.2f\t%.2f\t%.2f\t%s\t%s\t%s\n", p.Score, p.Levenshtein, p.TokenOverlap, embedding, p.A, p.B)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d likely duplicate pairs among %d concepts; merge them with: kg-builder merge --into CONCEPT DUPLICATE\n", len(pairs), len(names))
+	return nil
+}
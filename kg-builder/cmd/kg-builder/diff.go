@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// diffOptions are the flags of "kg-builder diff".
+type diffOptions struct {
+	from   string
+	to     string
+	asJSON bool
+}
+
+// newDiffCommand returns "kg-builder diff", which compares the neighborhood of a concept at two times.
+func newDiffCommand() *cobra.Command {
+	var opts diffOptions
+	cmd := &cobra.Command{
+		Use:   "diff --from TIME [--to TIME] CONCEPT",
+		Short: "Compare the neighborhood of a concept at two times",
+		Args:  cobra.ExactArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runDiffCommand(cfg, args[0], opts)
+		}),
+	}
+	cmd.Flags().StringVar(&opts.from, "from", "", "Earlier time, as 2006-01-02 or RFC 3339")
+	cmd.Flags().StringVar(&opts.to, "to", "", "Later time, as 2006-01-02 or RFC 3339 (default now)")
+	cmd.Flags().BoolVar(&opts.asJSON, "json", false, "Print the differences as JSON")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+func runDiffCommand(cfg *config.Config, concept string, opts diffOptions) error {
+	from, err := parseTime(opts.from)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to := time.Now()
+	if opts.to != "" {
+		if to, err = parseTime(opts.to); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if !to.After(from) {
+		return fmt.Errorf("--to must be after --from")
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	diff, err := neo4j.ConceptEgoDiff(driver, concept, from, to)
+	if err != nil {
+		return err
+	}
+	if opts.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	printEgoDiff(os.Stdout, diff)
+	return nil
+}
+
+// parseTime parses a date, taken as midnight local time, or an RFC 3339 time.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func printEgoDiff(out io.Writer, diff *neo4j.EgoDiff) {
+	fmt.Fprintf(out, "%s from %s to %s\n", diff.Concept, diff.From.Format(time.RFC3339), diff.To.Format(time.RFC3339))
+	fmt.Fprintf(out, "Added neighbors (%d): %s\n", len(diff.AddedNeighbors), strings.Join(diff.AddedNeighbors, ", "))
+	fmt.Fprintf(out, "Removed neighbors (%d): %s\n", len(diff.RemovedNeighbors), strings.Join(diff.RemovedNeighbors, ", "))
+	fmt.Fprintf(out, "Relations changed with existing neighbors (%d):\n", len(diff.ChangedRelations))
+	for _, c := range diff.ChangedRelations {
+		fmt.Fprintf(out, "  %s: %s -> %s\n", c.Neighbor, relationTypes(c.Before), relationTypes(c.After))
+	}
+	fmt.Fprintf(out, "Added relationships (%d):\n", len(diff.AddedRelationships))
+	for _, r := range diff.AddedRelationships {
+		fmt.Fprintf(out, "  + %s\n", describeEvent(diff.Concept, neo4j.TimelineEvent{Relationship: &r}))
+	}
+	fmt.Fprintf(out, "Removed relationships (%d):\n", len(diff.RemovedRelationships))
+	for _, r := range diff.RemovedRelationships {
+		fmt.Fprintf(out, "  - %s\n", describeEvent(diff.Concept, neo4j.TimelineEvent{Relationship: &r}))
+	}
+	if diff.Undated > 0 {
+		fmt.Fprintf(out, "%d relationships have no creation time and were assumed to predate both times.\n", diff.Undated)
+	}
+}
+
+// relationTypes lists the relation types of the relationships, e.g. "IsA, PartOf".
+func relationTypes(relationships []neo4j.Relationship) string {
+	types := make([]string, 0, len(relationships))
+	for _, r := range relationships {
+		types = append(types, r.Type)
+	}
+	return strings.Join(types, ", ")
+}
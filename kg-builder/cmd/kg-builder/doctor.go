@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"kg-builder/internal/config"
+	"kg-builder/internal/llm"
+	"kg-builder/internal/neo4j"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// doctorTimeout bounds each network check of the doctor command.
+const doctorTimeout = 5 * time.Second
+
+// doctorCheck is one line of the doctor checklist.
+type doctorCheck struct {
+	name   string
+	status string // ok, warn, fail or skip
+	detail string
+	fix    string // What to do about a warning or failure
+}
+
+// runDoctorCommand implements "kg-builder doctor", which checks the environment and prints an actionable checklist.
+// It loads the configuration itself so that configuration errors are reported as a check.
+func runDoctorCommand() error {
+	var checks []doctorCheck
+	cfg, err := config.Load()
+	if err != nil {
+		checks = append(checks, doctorCheck{"Configuration", "fail", err.Error(), "Fix the environment variable named above; see the Configuration table in the README"})
+	} else {
+		checks = append(checks, doctorCheck{name: "Configuration", status: "ok", detail: "all settings are valid"})
+	}
+
+	checks = append(checks, checkNeo4j()...)
+	if cfg != nil {
+		checks = append(checks, checkLLM(cfg.LLM)...)
+		if cfg.LLM.CacheEnabled {
+			checks = append(checks, checkWritableDir("LLM cache directory", cfg.LLM.CacheDir, "LLM_CACHE_DIR"))
+		}
+		if cfg.Graph.WALPath != "" {
+			checks = append(checks, checkWritableDir("Write-ahead log directory", filepath.Dir(cfg.Graph.WALPath), "GRAPH_WAL_PATH"))
+		}
+	}
+
+	failed := 0
+	for _, c := range checks {
+		fmt.Printf("[%-4s] %s: %s\n", strings.ToUpper(c.status), c.name, c.detail)
+		if c.fix != "" && (c.status == "warn" || c.status == "fail") {
+			fmt.Printf("       -> %s\n", c.fix)
+		}
+		if c.status == "fail" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkNeo4j checks that Neo4j is reachable, accepts the credentials and runs a supported version.
+func checkNeo4j() []doctorCheck {
+	uri := os.Getenv("NEO4J_URI")
+	if uri == "" {
+		return []doctorCheck{{"Neo4j", "fail", "NEO4J_URI is not set", "Set NEO4J_URI, e.g. bolt://localhost:7687"}}
+	}
+	if check := checkPort("Neo4j port", uri, "7687", "Start Neo4j (docker compose up -d neo4j) or fix NEO4J_URI"); check.status != "ok" {
+		return []doctorCheck{check}
+	}
+	checks := []doctorCheck{{name: "Neo4j port", status: "ok", detail: uri + " is reachable"}}
+
+	driver, err := neo4j.ConnectOnce()
+	if err != nil {
+		return append(checks, doctorCheck{"Neo4j connectivity", "fail", err.Error(), "Check NEO4J_USER and NEO4J_PASSWORD"})
+	}
+	defer driver.Close()
+	checks = append(checks, doctorCheck{name: "Neo4j connectivity", status: "ok", detail: "authenticated as " + os.Getenv("NEO4J_USER")})
+
+	info, err := neo4j.GetServerInfo(driver)
+	if err != nil {
+		return append(checks, doctorCheck{"Neo4j version", "fail", err.Error(), "Check that the user may call dbms.components() and SHOW PROCEDURES"})
+	}
+	version := doctorCheck{name: "Neo4j version", status: "ok", detail: info.Version + " " + info.Edition}
+	if !strings.HasPrefix(info.Version, "4.") {
+		version.status = "warn"
+		version.fix = "kg-builder uses the Neo4j 4.x driver and is tested with Neo4j 4.4 (the docker-compose image)"
+	}
+	checks = append(checks, version)
+	checks = append(checks, doctorCheck{name: "Neo4j plugins", status: "ok", detail: fmt.Sprintf("APOC %s, GDS %s (neither is required)", installed(info.APOC), installed(info.GDS))})
+	return checks
+}
+
+// checkLLM checks that the LLM endpoints are reachable and serve the configured models.
+func checkLLM(cfg config.LLMConfig) []doctorCheck {
+	if cfg.Provider == "synthetic" {
+		return []doctorCheck{{name: "LLM", status: "skip", detail: "LLM_PROVIDER=synthetic does not use an LLM"}}
+	}
+	var checks []doctorCheck
+	for _, url := range cfg.URLs {
+		if check := checkPort("LLM endpoint", url, "11434", "Start Ollama (ollama serve) or fix LLM_URL"); check.status != "ok" {
+			return append(checks, check)
+		}
+		checks = append(checks, doctorCheck{name: "LLM endpoint", status: "ok", detail: url + " is reachable"})
+	}
+
+	cfg.CacheEnabled = false // Checking the models must not create cache partitions
+	client, err := llm.NewClient(cfg)
+	if err != nil {
+		return append(checks, doctorCheck{"LLM models", "fail", err.Error(), "Fix LLM_PROMPTS_FILE or LLM_EXAMPLES_FILE"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	missing, err := client.MissingModels(ctx)
+	switch {
+	case err != nil:
+		return append(checks, doctorCheck{"LLM models", "fail", err.Error(), "Check that LLM_URL points to an Ollama server"})
+	case len(missing) == 0:
+		return append(checks, doctorCheck{name: "LLM models", status: "ok", detail: "all configured models are available"})
+	case cfg.AutoPull:
+		return append(checks, doctorCheck{"LLM models", "warn", "not available: " + strings.Join(missing, ", "), "They will be pulled on the next build; pull them now with ollama pull to save time"})
+	default:
+		return append(checks, doctorCheck{"LLM models", "fail", "not available: " + strings.Join(missing, ", "), "Run ollama pull for each model or set LLM_AUTO_PULL=true"})
+	}
+}
+
+// checkPort checks that something listens on the host and port of the URL, using defaultPort if it has none.
+func checkPort(name, rawURL, defaultPort, fix string) doctorCheck {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return doctorCheck{name, "fail", fmt.Sprintf("invalid URL %q", rawURL), fix}
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	address := net.JoinHostPort(u.Hostname(), port)
+	conn, err := net.DialTimeout("tcp", address, doctorTimeout)
+	if err != nil {
+		return doctorCheck{name, "fail", fmt.Sprintf("nothing reachable at %s: %v", address, err), fix}
+	}
+	conn.Close()
+	return doctorCheck{name: name, status: "ok"}
+}
+
+// checkWritableDir checks that files can be created in dir, or in the closest existing parent if dir does not exist
+// yet, since the builder creates it.
+func checkWritableDir(name, dir, variable string) doctorCheck {
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return doctorCheck{name, "fail", existing + " is not a directory", "Set " + variable + " to a directory path"}
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) || filepath.Dir(existing) == existing {
+			return doctorCheck{name, "fail", err.Error(), "Fix the permissions of " + dir + " or set " + variable}
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	f, err := os.CreateTemp(existing, ".doctor-*")
+	if err != nil {
+		return doctorCheck{name, "fail", fmt.Sprintf("%s is not writable: %v", existing, err), "Fix the permissions of " + existing + " or set " + variable}
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	if existing != dir {
+		return doctorCheck{name: name, status: "ok", detail: dir + " will be created"}
+	}
+	return doctorCheck{name: name, status: "ok", detail: dir + " is writable"}
+}
+
+// installed describes whether a plugin is installed.
+func installed(ok bool) string {
+	if ok {
+		return "installed"
+	}
+	return "not installed"
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/eval"
+	"kg-builder/internal/llm"
+	"kg-builder/internal/models"
+	"kg-builder/internal/sample"
+	"kg-builder/internal/synthetic"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// evalOptions are the flags of "kg-builder eval".
+type evalOptions struct {
+	goldFile        string
+	predictionsFile string
+	recordFile      string
+	maxSeeds        int
+	mine            bool
+	out             string
+	minF1           float64
+}
+
+// newEvalCommand returns "kg-builder eval", which expands the seeds of a gold standard and reports the precision,
+// recall and F1 of the concepts and relationships found, so prompt and model changes can be regression-tested.
+func newEvalCommand() *cobra.Command {
+	var opts evalOptions
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Score extraction against a gold standard",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runEvalCommand(cfg, opts)
+		}),
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.goldFile, "gold", "", "Gold relationships as a JSON array of {name, relation, relatedTo} (default the bundled sample graph)")
+	flags.StringVar(&opts.predictionsFile, "predictions", "", "Score expansions recorded with --record instead of running the LLM")
+	flags.StringVar(&opts.recordFile, "record", "", "Write the expansions to this file, for later runs with --predictions")
+	flags.IntVar(&opts.maxSeeds, "seeds", 0, "Maximum number of seeds expanded (0 for all)")
+	flags.BoolVar(&opts.mine, "mine", false, "Also mine each gold pair and score the relation types found")
+	flags.StringVar(&opts.out, "out", "", "Write the full report, with the missing and unexpected relationships of each seed, as JSON")
+	flags.Float64Var(&opts.minF1, "min-f1", 0, "Fail if the relationship F1 is below this value")
+	cmd.MarkFlagsMutuallyExclusive("predictions", "record")
+	cmd.MarkFlagsMutuallyExclusive("predictions", "mine")
+	return cmd
+}
+
+func runEvalCommand(cfg *config.Config, opts evalOptions) error {
+	gold, err := sample.Relationships()
+	if opts.goldFile != "" {
+		gold, err = eval.LoadGold(opts.goldFile)
+	}
+	if err != nil {
+		return err
+	}
+	seeds := eval.Seeds(gold)
+	if opts.maxSeeds > 0 && opts.maxSeeds < len(seeds) {
+		seeds = seeds[:opts.maxSeeds]
+		gold = eval.FromSeeds(gold, seeds)
+	}
+
+	var predicted, mined []models.Concept
+	if opts.predictionsFile != "" {
+		if predicted, err = eval.LoadGold(opts.predictionsFile); err != nil {
+			return err
+		}
+	} else {
+		getRelatedConcepts, mineRelationship, closeClient, err := evalProvider(cfg)
+		if err != nil {
+			return err
+		}
+		defer closeClient()
+		for i, seed := range seeds {
+			log.Printf("Expanding seed %d/%d: %s", i+1, len(seeds), seed)
+			related, err := getRelatedConcepts(seed)
+			if err != nil {
+				return fmt.Errorf("failed to expand %s: %w", seed, err)
+			}
+			for _, c := range related {
+				c.RelatedTo = seed // Score the expansion of the seed even if the LLM echoes it differently
+				predicted = append(predicted, c)
+			}
+		}
+		if opts.mine {
+			for _, r := range gold {
+				found, err := mineRelationship(r.RelatedTo, r.Name)
+				if err != nil {
+					return fmt.Errorf("failed to mine %s and %s: %w", r.RelatedTo, r.Name, err)
+				}
+				if found != nil {
+					mined = append(mined, models.Concept{Name: r.Name, Relation: found.Relation, RelatedTo: r.RelatedTo})
+				}
+			}
+		}
+		if opts.recordFile != "" {
+			if err := writeJSONFile(opts.recordFile, predicted); err != nil {
+				return err
+			}
+		}
+	}
+
+	report := eval.Evaluate(gold, predicted, seeds)
+	if opts.mine {
+		score := eval.EvaluateMining(gold, mined)
+		report.Mining = &score
+	}
+	if err := printEvalReport(os.Stdout, len(seeds), report); err != nil {
+		return err
+	}
+	if opts.out != "" {
+		if err := writeJSONFile(opts.out, report); err != nil {
+			return err
+		}
+	}
+	if f1 := report.Relations.F1(); f1 < opts.minF1 {
+		return fmt.Errorf("relationship F1 %.3f is below %.3f", f1, opts.minF1)
+	}
+	return nil
+}
+
+// evalProvider returns the expansion and mining functions of the configured provider and a function releasing it.
+func evalProvider(cfg *config.Config) (func(string) ([]models.Concept, error), func(string, string) (*models.Concept, error), func(), error) {
+	if cfg.LLM.Provider == "synthetic" {
+		generator := synthetic.NewGenerator(cfg.LLM.SyntheticSize, cfg.LLM.RelatedCount, cfg.LLM.SyntheticSeed)
+		return generator.GetRelatedConcepts, generator.MineRelationship, func() {}, nil
+	}
+	client, err := llm.NewClient(cfg.LLM)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create LLM client: %w", err)
+	}
+	if err := client.EnsureModel(context.Background()); err != nil {
+		return nil, nil, nil, fmt.Errorf("LLM model check failed: %w", err)
+	}
+	closeClient := func() {
+		if err := client.Close(); err != nil {
+			log.Printf("Failed to save LLM cache statistics: %v", err)
+		}
+	}
+	return client.GetRelatedConcepts, client.MineRelationship, closeClient, nil
+}
+
+// printEvalReport writes the scores of the report as a table.
+func printEvalReport(w io.Writer, seeds int, report eval.Report) error {
+	fmt.Fprintf(w, "Evaluated %d seeds\n\n", seeds)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tPRECISION\tRECALL\tF1\tMATCHED\tPREDICTED\tGOLD")
+	rows := []struct {
+		name  string
+		score *eval.Score
+	}{{"concepts", &report.Concepts}, {"relationships", &report.Relations}, {"mining", report.Mining}}
+	for _, row := range rows {
+		if row.score == nil {
+			continue
+		}
+		s := *row.score
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%.3f\t%d\t%d\t%d\n", row.name, s.Precision(), s.Recall(), s.F1(), s.Matched, s.Predicted, s.Gold)
+	}
+	return tw.Flush()
+}
+
+// writeJSONFile writes v to the named file as indented JSON.
+func writeJSONFile(name string, v interface{}) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetEscapeHTML(false) // Keep the arrows of the relationships readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return f.Close()
+}
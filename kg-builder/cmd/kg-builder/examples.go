@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/llm"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// newExamplesCommand returns "kg-builder examples", whose subcommands inspect the few-shot example library.
+func newExamplesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "examples",
+		Short: "Inspect the few-shot example library",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the domains of LLM_EXAMPLES_FILE with their example counts",
+		Args:  cobra.NoArgs,
+		RunE: withExampleLibrary(func(cfg *config.Config, library map[string]llm.ExampleSet, args []string) error {
+			domains := make([]string, 0, len(library))
+			for domain := range library {
+				domains = append(domains, domain)
+			}
+			sort.Strings(domains)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "DOMAIN\tEXPANSIONS\tRELATIONSHIPS\tUNRELATED\tSELECTED")
+			for _, domain := range domains {
+				set := library[domain]
+				selected := ""
+				if domain == cfg.LLM.ExamplesDomain {
+					selected = "*"
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", domain, len(set.Expansions), len(set.Relationships), len(set.Unrelated), selected)
+			}
+			return w.Flush()
+		}),
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show [DOMAIN]",
+		Short: "Print the example text added to the prompts for DOMAIN (default LLM_EXAMPLES_DOMAIN)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: withExampleLibrary(func(cfg *config.Config, library map[string]llm.ExampleSet, args []string) error {
+			domain := cfg.LLM.ExamplesDomain
+			if len(args) == 1 {
+				domain = args[0]
+			}
+			set, ok := library[domain]
+			if !ok {
+				return fmt.Errorf("examples file %s has no domain %q", cfg.LLM.ExamplesFile, domain)
+			}
+			fmt.Printf("Concept expansion prompts:%s\n", set.ExpansionBlock())
+			fmt.Printf("Relationship mining prompts:%s\n", set.RelationshipBlock())
+			return nil
+		}),
+	})
+	return cmd
+}
+
+// withExampleLibrary is withConfig for the examples subcommands, which are given the library in LLM_EXAMPLES_FILE.
+func withExampleLibrary(run func(cfg *config.Config, library map[string]llm.ExampleSet, args []string) error) func(*cobra.Command, []string) error {
+	return withConfig(func(cfg *config.Config, args []string) error {
+		if cfg.LLM.ExamplesFile == "" {
+			return fmt.Errorf("LLM_EXAMPLES_FILE is not set")
+		}
+		library, err := llm.LoadExampleLibrary(cfg.LLM.ExamplesFile)
+		if err != nil {
+			return err
+		}
+		return run(cfg, library, args)
+	})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/export"
+	"kg-builder/internal/neo4j"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// diagramFormats write the neighborhood of a concept.
+var diagramFormats = map[string]func(w io.Writer, graph *neo4j.Graph, focus string) error{
+	"mermaid": export.Mermaid,
+	"dot":     export.DOT,
+}
+
+// streamFormats write the whole graph as it is read from Neo4j.
+var streamFormats = map[string]func(w io.Writer) export.GraphWriter{
+	"graphml": export.NewGraphML,
+	"gexf":    export.NewGEXF,
+}
+
+// rdfFormats write the whole graph as RDF, mapped to IRIs by the --mapping file.
+var rdfFormats = map[string]func(w io.Writer, mapping *export.RDFMapping) export.GraphWriter{
+	"jsonld": export.NewJSONLD,
+	"turtle": export.NewTurtle,
+}
+
+// tableFormats write the concepts and relationships selected by --records as rows, for pandas and spreadsheets.
+var tableFormats = map[string]func(w io.Writer, records string) export.GraphWriter{
+	"csv":   export.NewCSV,
+	"jsonl": export.NewJSONLines,
+}
+
+// exportOptions are the flags of "kg-builder export".
+type exportOptions struct {
+	format      string
+	out         string
+	concept     string
+	depth       int
+	limit       int
+	records     string
+	mappingFile string
+	scrub       bool
+	every       time.Duration
+	excludeTags string
+}
+
+// newExportCommand returns "kg-builder export", which writes the graph in a format other tools can read.
+func newExportCommand() *cobra.Command {
+	var opts exportOptions
+	cmd := &cobra.Command{
+		Use:   "export --format FORMAT",
+		Short: "Export the graph to other tools",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runExportCommand(opts)
+		}),
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.format, "format", "", "Export format: obsidian, cypher, graphml, gexf, jsonld, turtle, csv, jsonl, mermaid or dot")
+	flags.StringVar(&opts.out, "out", "", "Output directory (obsidian) or file (default standard output)")
+	flags.StringVar(&opts.concept, "concept", "", "Concept whose neighborhood is drawn (mermaid, dot)")
+	flags.IntVar(&opts.depth, "depth", 1, "Number of relationships from the concept included (mermaid, dot)")
+	flags.IntVar(&opts.limit, "limit", 50, "Maximum number of concepts drawn (mermaid, dot)")
+	flags.StringVar(&opts.records, "records", export.RecordsAll, "Records written: concepts, relationships or all (csv, jsonl; csv needs one kind)")
+	flags.StringVar(&opts.mappingFile, "mapping", "", "JSON file mapping concepts and relation types to IRIs (jsonld, turtle)")
+	flags.BoolVar(&opts.scrub, "scrub", false, "Export the topology only: drop descriptions, tags, aliases, provenance and other properties")
+	flags.DurationVar(&opts.every, "every", 0, "Export again at this interval until interrupted, replacing --out each time")
+	flags.StringVar(&opts.excludeTags, "exclude-tags", "", "Comma-separated tags whose concepts are dropped with their relationships; implies --scrub")
+	cmd.MarkFlagRequired("format")
+	return cmd
+}
+
+func runExportCommand(opts exportOptions) error {
+	var scrubber *export.Scrub
+	if opts.scrub || opts.excludeTags != "" {
+		scrubber = export.NewScrub(splitList(opts.excludeTags))
+	}
+
+	diagram, isDiagram := diagramFormats[opts.format]
+	newGraphWriter, isStream := streamFormats[opts.format]
+	if newRDFWriter, isRDF := rdfFormats[opts.format]; isRDF {
+		mapping, err := export.LoadRDFMapping(opts.mappingFile)
+		if err != nil {
+			return err
+		}
+		newGraphWriter = func(w io.Writer) export.GraphWriter { return newRDFWriter(w, mapping) }
+		isStream = true
+	}
+	if newTable, isTable := tableFormats[opts.format]; isTable {
+		newGraphWriter = func(w io.Writer) export.GraphWriter { return newTable(w, opts.records) }
+		isStream = true
+	}
+	switch {
+	case opts.records != export.RecordsAll && opts.records != export.RecordsConcepts && opts.records != export.RecordsRelationships:
+		return fmt.Errorf("invalid --records %q: must be concepts, relationships or all", opts.records)
+	case opts.format == "csv" && opts.records == export.RecordsAll:
+		return fmt.Errorf("the csv format needs --records %s or %s", export.RecordsConcepts, export.RecordsRelationships)
+	case opts.format == "obsidian" && opts.out == "":
+		return fmt.Errorf("the obsidian format needs --out")
+	case isDiagram && opts.concept == "":
+		return fmt.Errorf("the %s format needs --concept", opts.format)
+	case opts.format != "obsidian" && opts.format != "cypher" && !isDiagram && !isStream:
+		return fmt.Errorf("unknown export format %q", opts.format)
+	case opts.every < 0 || (opts.every > 0 && opts.out == ""):
+		return fmt.Errorf("--every needs a positive interval and --out")
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	exportGraph := func() error {
+		if isDiagram {
+			graph, err := neo4j.ReadNeighborhood(driver, opts.concept, opts.depth, opts.limit)
+			if err != nil {
+				return err
+			}
+			if scrubber != nil {
+				graph = scrubber.Graph(graph)
+			}
+			return writeOutput(opts.out, func(w io.Writer) error { return diagram(w, graph, opts.concept) })
+		}
+
+		if isStream {
+			return writeOutput(opts.out, func(w io.Writer) error {
+				gw := newGraphWriter(w)
+				if _, isTable := tableFormats[opts.format]; isTable && opts.records != export.RecordsRelationships {
+					degrees, err := neo4j.ConceptDegrees(driver)
+					if err != nil {
+						return err
+					}
+					gw = export.WithDegrees(gw, degrees)
+				}
+				if scrubber != nil {
+					gw = scrubber.Writer(gw)
+				}
+				if err := neo4j.StreamGraph(driver, gw.Concept, gw.Relationship); err != nil {
+					return err
+				}
+				return gw.Close()
+			})
+		}
+
+		graph, err := neo4j.ReadGraph(driver)
+		if err != nil {
+			return err
+		}
+		if scrubber != nil {
+			graph = scrubber.Graph(graph)
+		}
+		if opts.format == "cypher" {
+			return writeOutput(opts.out, func(w io.Writer) error { return export.Cypher(w, graph) })
+		}
+		if err := export.Obsidian(graph, opts.out); err != nil {
+			return err
+		}
+		log.Printf("Exported %d concepts and %d relationships to %s", len(graph.Concepts), len(graph.Relationships), opts.out)
+		return nil
+	}
+
+	if opts.every == 0 {
+		return exportGraph()
+	}
+	for {
+		start := time.Now()
+		if err := exportGraph(); err != nil {
+			log.Printf("Export to %s failed: %v", opts.out, err)
+		} else {
+			log.Printf("Exported to %s, next export in %s", opts.out, opts.every)
+		}
+		time.Sleep(time.Until(start.Add(opts.every)))
+	}
+}
+
+// writeOutput calls write with the named file, or standard output if name is empty. The file is replaced once
+// written, so readers never see a partial export.
+func writeOutput(name string, write func(w io.Writer) error) error {
+	if name == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	f.Chmod(0o644) // CreateTemp makes the file private
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
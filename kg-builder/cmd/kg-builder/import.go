@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/models"
+	"kg-builder/internal/neo4j"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// importBatchSize is the number of relationships or concepts written per transaction by "kg-builder import".
+const importBatchSize = 1000
+
+// importOptions are the flags of "kg-builder import".
+type importOptions struct {
+	format string
+	dryRun bool
+}
+
+// newImportCommand returns "kg-builder import", which loads concepts and relationships from CSV or JSON files,
+// merging them with the graph so an existing dataset can seed the build.
+func newImportCommand() *cobra.Command {
+	var opts importOptions
+	cmd := &cobra.Command{
+		Use:   "import FILE...",
+		Short: "Load concepts and relationships from CSV or JSON files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runImportCommand(args, opts)
+		}),
+	}
+	cmd.Flags().StringVar(&opts.format, "format", "", "Input format: csv or json (default from the file extension)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only parse the files and report what would be imported")
+	return cmd
+}
+
+func runImportCommand(files []string, opts importOptions) error {
+	var concepts []string
+	var relationships []neo4j.Relationship
+	for _, name := range files {
+		fileConcepts, fileRelationships, err := readImportFile(name, opts.format)
+		if err != nil {
+			return err
+		}
+		log.Printf("Read %d concepts and %d relationships from %s", len(fileConcepts), len(fileRelationships), name)
+		concepts = append(concepts, fileConcepts...)
+		relationships = append(relationships, fileRelationships...)
+	}
+	if opts.dryRun {
+		fmt.Printf("Would import %d concepts and %d relationships\n", len(concepts), len(relationships))
+		return nil
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	for start := 0; start < len(concepts); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(concepts) {
+			end = len(concepts)
+		}
+		if err := neo4j.CreateConcepts(driver, concepts[start:end]); err != nil {
+			return fmt.Errorf("failed to import concepts %d to %d: %w", start+1, end, err)
+		}
+	}
+	for start := 0; start < len(relationships); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(relationships) {
+			end = len(relationships)
+		}
+		if err := neo4j.CreateRelationshipsBatch(driver, relationships[start:end]); err != nil {
+			return fmt.Errorf("failed to import relationships %d to %d: %w", start+1, end, err)
+		}
+	}
+	log.Printf("Imported %d concepts and %d relationships", len(concepts), len(relationships))
+	return nil
+}
+
+// readImportFile reads the concepts without relationships and the relationships of an import file.
+func readImportFile(name, format string) ([]string, []neo4j.Relationship, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	var concepts []string
+	var relationships []neo4j.Relationship
+	switch format {
+	case "csv":
+		concepts, relationships, err = readImportCSV(f)
+	case "json":
+		concepts, relationships, err = readImportJSON(f)
+	default:
+		return nil, nil, fmt.Errorf("unknown import format %q for %s; use --format csv or --format json", format, name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return concepts, relationships, nil
+}
+
+// readImportCSV reads a CSV file with a header row: an edge list has from, to and relation columns and an optional
+// description column, a node list a name column.
+func readImportCSV(r io.Reader) ([]string, []neo4j.Relationship, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	_, hasFrom := columns["from"]
+	_, hasTo := columns["to"]
+	_, hasName := columns["name"]
+	if !(hasFrom && hasTo) && !hasName {
+		return nil, nil, fmt.Errorf("the header needs from and to columns (edge list) or a name column (node list)")
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var concepts []string
+	var relationships []neo4j.Relationship
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if hasFrom && hasTo {
+			from, to := field(record, "from"), field(record, "to")
+			if from == "" || to == "" {
+				return nil, nil, fmt.Errorf("line %d: from and to are required", line)
+			}
+			relationships = append(relationships, neo4j.Relationship{
+				From:        from,
+				To:          to,
+				Type:        field(record, "relation"),
+				Description: field(record, "description"),
+			})
+			continue
+		}
+		if name := field(record, "name"); name != "" {
+			concepts = append(concepts, name)
+		}
+	}
+	return concepts, relationships, nil
+}
+
+// readImportJSON reads a JSON array of relationships in the format the LLM returns them, like the bundled sample
+// graph. Entries without relatedTo are concepts without relationships.
+func readImportJSON(r io.Reader) ([]string, []neo4j.Relationship, error) {
+	var entries []models.Concept
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, nil, err
+	}
+	var concepts []string
+	var relationships []neo4j.Relationship
+	for i, e := range entries {
+		name, relatedTo := strings.TrimSpace(e.Name), strings.TrimSpace(e.RelatedTo)
+		switch {
+		case name == "":
+			return nil, nil, fmt.Errorf("entry %d: name is required", i+1)
+		case relatedTo == "":
+			concepts = append(concepts, name)
+		default:
+			relationships = append(relationships, neo4j.Relationship{From: relatedTo, To: name, Type: e.Relation})
+		}
+	}
+	return concepts, relationships, nil
+}
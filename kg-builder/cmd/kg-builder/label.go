@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// labelingItem is one relationship of a labeling dataset. Label is left empty for the annotator.
+type labelingItem struct {
+	ID                  int    `json:"id"`
+	From                string `json:"from"`
+	Relation            string `json:"relation"`
+	To                  string `json:"to"`
+	RelationDescription string `json:"relationDescription,omitempty"`
+	FromDescription     string `json:"fromDescription,omitempty"`
+	ToDescription       string `json:"toDescription,omitempty"`
+	Label               string `json:"label"`
+}
+
+// labelSampleOptions are the flags of "kg-builder label-sample".
+type labelSampleOptions struct {
+	n      int
+	format string
+	out    string
+}
+
+// newLabelSampleCommand returns "kg-builder label-sample", which samples relationships stratified by relation type
+// into a labeling-ready dataset for measuring extraction precision.
+func newLabelSampleCommand() *cobra.Command {
+	var opts labelSampleOptions
+	cmd := &cobra.Command{
+		Use:   "label-sample",
+		Short: "Sample relationships into a labeling dataset",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runLabelSampleCommand(opts)
+		}),
+	}
+	cmd.Flags().IntVar(&opts.n, "n", 200, "Number of relationships to sample")
+	cmd.Flags().StringVar(&opts.format, "format", "jsonl", "Output format: jsonl or csv")
+	cmd.Flags().StringVar(&opts.out, "out", "", "Output file (default standard output)")
+	return cmd
+}
+
+func runLabelSampleCommand(opts labelSampleOptions) error {
+	if opts.n <= 0 {
+		return fmt.Errorf("--n must be positive")
+	}
+	if opts.format != "jsonl" && opts.format != "csv" {
+		return fmt.Errorf("unknown format %q", opts.format)
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	counts, err := neo4j.RelationTypeCounts(driver)
+	if err != nil {
+		return err
+	}
+	quotas := stratify(counts, opts.n)
+
+	types := make([]string, 0, len(quotas))
+	for t := range quotas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	var items []labelingItem
+	for _, t := range types {
+		sample, err := neo4j.SampleRelationships(driver, t, quotas[t])
+		if err != nil {
+			return err
+		}
+		for _, s := range sample {
+			items = append(items, labelingItem{
+				ID:                  len(items) + 1,
+				From:                s.From,
+				Relation:            s.Type,
+				To:                  s.To,
+				RelationDescription: s.Description,
+				FromDescription:     s.FromDescription,
+				ToDescription:       s.ToDescription,
+			})
+		}
+	}
+
+	err = writeOutput(opts.out, func(w io.Writer) error {
+		if opts.format == "csv" {
+			return writeLabelingCSV(w, items)
+		}
+		enc := json.NewEncoder(w)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Sampled %d relationships of %d relation types", len(items), len(quotas))
+	return nil
+}
+
+// stratify splits n samples among the relation types in proportion to their counts, using the largest remainders,
+// with at least one sample per type while n allows, so rare relation types are represented too.
+func stratify(counts map[string]int64, n int) map[string]int {
+	var total int64
+	types := make([]string, 0, len(counts))
+	for t, c := range counts {
+		if c > 0 {
+			total += c
+			types = append(types, t)
+		}
+	}
+	// Larger types first, so they keep their samples when n is smaller than the number of types
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	quotas := make(map[string]int)
+	if total == 0 {
+		return quotas
+	}
+	if int64(n) >= total {
+		for _, t := range types {
+			quotas[t] = int(counts[t])
+		}
+		return quotas
+	}
+
+	assigned := 0
+	for _, t := range types {
+		if assigned < n {
+			quotas[t] = 1
+			assigned++
+		}
+	}
+	remainders := make(map[string]float64, len(types))
+	rest := n - assigned
+	for _, t := range types {
+		share := float64(rest) * float64(counts[t]) / float64(total)
+		extra := int(share)
+		if int64(quotas[t]+extra) > counts[t] {
+			extra = int(counts[t]) - quotas[t]
+		}
+		quotas[t] += extra
+		assigned += extra
+		remainders[t] = share - float64(int(share))
+	}
+	sort.SliceStable(types, func(i, j int) bool { return remainders[types[i]] > remainders[types[j]] })
+	for assigned < n {
+		progress := false
+		for _, t := range types {
+			if assigned < n && int64(quotas[t]) < counts[t] {
+				quotas[t]++
+				assigned++
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	for t, q := range quotas {
+		if q == 0 {
+			delete(quotas, t)
+		}
+	}
+	return quotas
+}
+
+// writeLabelingCSV writes the items as CSV with a header row.
+func writeLabelingCSV(w io.Writer, items []labelingItem) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "from", "relation", "to", "relation_description", "from_description", "to_description", "label"})
+	for _, item := range items {
+		cw.Write([]string{strconv.Itoa(item.ID), item.From, item.Relation, item.To, item.RelationDescription, item.FromDescription, item.ToDescription, item.Label})
+	}
+	cw.Flush()
+	return cw.Error()
+}
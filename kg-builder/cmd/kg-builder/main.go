@@ -1,46 +1,296 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"kg-builder/internal/config"
 	"kg-builder/internal/graph"
 	"kg-builder/internal/llm"
+	"kg-builder/internal/logging"
+	"kg-builder/internal/models"
 	"kg-builder/internal/neo4j"
+	"kg-builder/internal/screen"
+	"kg-builder/internal/synthetic"
+	"kg-builder/internal/wal"
 	"log"
+	"log/slog"
 	"os"
+	"runtime"
+	"strings"
 	"time"
+
+	"github.com/spf13/cobra"
 )
 
+// version is the version of the binary, set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+// subcommands are the constructors of the maintenance commands, in the order they are listed in the help. Each
+// registers its flags on its command and loads the configuration with withConfig when it runs.
+var subcommands = []func() *cobra.Command{
+	newCacheCommand, // Cache management does not need Neo4j or the LLM
+	newStatsCommand,
+	newCleanupCommand,
+	newTimelineCommand,
+	newDiffCommand,
+	newClusterCommand,
+	newSeedSampleCommand,
+	newImportCommand,
+	newMergeCommand,
+	newSplitCommand,
+	newDuplicatesCommand,
+	newTagCommand,
+	newIndexesCommand,
+	newMigrateRelationsCommand,
+	newRenameRelationsCommand,
+	newExamplesCommand,
+	newExportCommand,
+	newLabelSampleCommand,
+	newEvalCommand,
+	newReviewCommand,
+}
+
+// buildOptions are the flags of "kg-builder build".
+type buildOptions struct {
+	seed        string
+	maxNodes    int
+	timeout     time.Duration
+	mine        int
+	mineWorkers int
+}
+
 func main() {
-	log.Println("Starting Knowledge Graph Builder") // Log the start of the application
+	if err := newRootCommand().Execute(); err != nil {
+		fatal(err.Error())
+	}
+}
+
+// newRootCommand returns the kg-builder command. Run without a subcommand, it builds the graph with the default
+// options, as the Docker image does.
+func newRootCommand() *cobra.Command {
+	opts := buildOptions{seed: "Artificial Intelligence", timeout: 30 * time.Minute, mine: 50, mineWorkers: 5}
+	root := &cobra.Command{
+		Use:           "kg-builder",
+		Short:         "Build a knowledge graph in Neo4j with an LLM",
+		Version:       version,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			setupLogging()
+		},
+		Run: func(cmd *cobra.Command, args []string) { runBuild(opts) },
+	}
+
+	build := &cobra.Command{
+		Use:   "build",
+		Short: "Build the graph from a seed concept (the default)",
+		Args:  cobra.NoArgs,
+		Run:   func(cmd *cobra.Command, args []string) { runBuild(opts) },
+	}
+	build.Flags().StringVar(&opts.seed, "seed", opts.seed, "Concept the build starts from")
+	build.Flags().IntVar(&opts.maxNodes, "max-nodes", 0, "Maximum number of concepts to add (default GRAPH_MAX_NODES)")
+	build.Flags().DurationVar(&opts.timeout, "timeout", opts.timeout, "Time after which the build stops")
+	build.Flags().IntVar(&opts.mine, "mine", opts.mine, "Random relationships to mine after the build (0 skips mining)")
+	build.Flags().IntVar(&opts.mineWorkers, "mine-workers", opts.mineWorkers, "Concurrent mining requests")
+	root.AddCommand(build)
+
+	root.AddCommand(&cobra.Command{
+		Use:   "version",
+		Short: "Print the version",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("kg-builder %s (%s)\n", version, runtime.Version())
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment and print a checklist of problems with fixes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Runs before the configuration is loaded so it can report errors in it
+			return commandError(cmd, runDoctorCommand())
+		},
+	})
+
+	for _, newCommand := range subcommands {
+		root.AddCommand(newCommand())
+	}
+	return root
+}
 
-	// Log all environment variables
-	log.Println("Environment variables:")
-	for _, env := range os.Environ() { // Iterate through environment variables
-		log.Println(env) // Log each environment variable
+// withConfig returns the RunE of a maintenance command: it loads the configuration, applies the Neo4j settings and
+// runs the command with the configuration and its arguments.
+func withConfig(run func(cfg *config.Config, args []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		applyNeo4jSettings(cfg)
+		return commandError(cmd, run(cfg, args))
+	}
+}
+
+// setupLogging applies LOG_LEVEL and LOG_FORMAT, and tags every record with a new run ID. Invalid settings fall back to the defaults with a warning, so
+// doctor can still run and report them; the other commands then fail loading the configuration.
+func setupLogging() {
+	logCfg, err := config.LoadLog()
+	if err != nil {
+		logCfg = config.LogConfig{Level: "info", Format: logging.FormatText}
+	}
+	logging.SetRunID(logging.NewRunID())
+	if err := logging.Setup(logCfg.Level, logCfg.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err) // Already validated by config.LoadLog
+	}
+	if err != nil {
+		slog.Warn("Invalid logging settings, using the defaults", "error", err)
+	}
+}
+
+// commandError wraps the error of a subcommand with its name, e.g. "cache list".
+func commandError(cmd *cobra.Command, err error) error {
+	if err == nil {
+		return nil
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	return fmt.Errorf("%s command failed: %w", name, err)
+}
+
+// runBuild builds the graph from the seed concept, then mines random relationships between the concepts it added.
+func runBuild(opts buildOptions) {
+	slog.Info("Starting Knowledge Graph Builder") // Log the start of the application
+
+	cfg, err := config.Load() // Load the configuration from the environment
+	if err != nil {
+		fatal("Failed to load configuration", "error", err)
+	}
+	if opts.mine > 0 && opts.mineWorkers < 1 {
+		fatal("Invalid --mine-workers: must be positive")
+	}
+	if cfg.Graph.ReadOnly { // Building only writes, so there is nothing to do
+		fatal("Cannot build the graph", "error", neo4j.ErrReadOnly)
+	}
+
+	var llmClient *llm.Client
+	var getRelatedConcepts func(string) ([]models.Concept, error)
+	var mineRelationship func(string, string) (*models.Concept, error)
+	if cfg.LLM.Provider == "synthetic" { // A generated ontology stands in for the LLM, e.g. for scale tests
+		generator := synthetic.NewGenerator(cfg.LLM.SyntheticSize, cfg.LLM.RelatedCount, cfg.LLM.SyntheticSeed)
+		getRelatedConcepts, mineRelationship = generator.GetRelatedConcepts, generator.MineRelationship
+		slog.Info("Using a synthetic ontology instead of the LLM", "concepts", cfg.LLM.SyntheticSize)
+	} else {
+		llmClient, err = llm.NewClient(cfg.LLM) // Create the LLM client
+		if err != nil {
+			fatal("Failed to create LLM client", "error", err)
+		}
+		if err := llmClient.EnsureModel(context.Background()); err != nil { // Make sure the model is available before building
+			fatal("LLM model check failed", "error", err)
+		}
+		if cfg.LLM.WarmUp {
+			if err := llmClient.WarmUp(context.Background()); err != nil { // Load the models now rather than on the first expansion
+				slog.Warn("LLM warm-up failed", "error", err)
+			}
+		}
+		getRelatedConcepts, mineRelationship = llmClient.GetRelatedConcepts, llmClient.MineRelationship
 	}
 
 	neo4jDriver, err := neo4j.SetupNeo4jConnection() // Set up connection to Neo4j database
 	if err != nil {
-		log.Fatalf("Failed to connect to Neo4j: %v", err) // Log fatal error if connection fails
+		fatal("Failed to connect to Neo4j", "error", err) // Log fatal error if connection fails
 	}
 	defer neo4jDriver.Close() // Ensure the Neo4j driver is closed when main exits
 
-	graphBuilder := graph.NewGraphBuilder(neo4jDriver, llm.GetRelatedConcepts, llm.MineRelationship) // Create a new graph builder
+	applyNeo4jSettings(cfg) // Apply the relation allowlist and query logging settings
 
-	seedConcept := "Artificial Intelligence" // Define the seed concept for graph building
-	maxNodes := 100                          // Set the maximum number of nodes to build
-	timeout := 30 * time.Minute              // Set the timeout for graph building
+	graphBuilder := graph.NewGraphBuilder(neo4jDriver, cfg.Graph, getRelatedConcepts, mineRelationship) // Create a new graph builder
 
-	log.Printf("Starting graph building with seed concept: %s", seedConcept) // Log the start of graph building
-	err = graphBuilder.BuildGraph(seedConcept, maxNodes, timeout)            // Build the graph
+	conceptFilter, err := graph.NewConceptFilter(cfg.Graph.ConceptFilters, cfg.Graph.StopConceptsFile) // Reject numbers, dates and stop concepts
 	if err != nil {
-		log.Printf("Graph building stopped: %v", err) // Log any errors during graph building
+		fatal("Failed to load concept filters", "error", err)
+	}
+	graphBuilder.SetConceptFilter(conceptFilter)
+
+	screener, err := screen.New(cfg.Graph.ScreenDetectors, cfg.Graph.ScreenWordlist) // Hold personal data and offensive words back for review
+	if err != nil {
+		fatal("Failed to load screening", "error", err)
+	}
+	if screener != nil {
+		reviewQueue, err := screen.OpenQueue(cfg.Graph.ReviewFile)
+		if err != nil {
+			fatal("Failed to open review queue", "error", err)
+		}
+		graphBuilder.SetScreening(screener, reviewQueue)
+	}
+	if llmClient != nil {
+		graphBuilder.SetLLMBreaker(llmClient.Breaker()) // Pause the workers while the LLM keeps failing
+	}
+
+	if cfg.Graph.WALPath != "" {
+		walLog, err := wal.Open(cfg.Graph.WALPath) // Open the write-ahead log of relationships awaiting commit
+		if err != nil {
+			fatal("Failed to open write-ahead log", "error", err)
+		}
+		defer walLog.Close()
+		graphBuilder.SetWriteAheadLog(walLog)
+
+		replayed, err := graphBuilder.ReplayWriteAheadLog() // Write what a previous run paid for but did not store
+		if err != nil {
+			fatal("Failed to replay write-ahead log", "error", err)
+		}
+		if replayed > 0 {
+			slog.Info("Replayed relationships from the write-ahead log", "relationships", replayed)
+		}
+	}
+
+	seedConcept := opts.seed // Define the seed concept for graph building
+	maxNodes := opts.maxNodes
+	if maxNodes <= 0 {
+		maxNodes = cfg.Graph.MaxNodes // Set the maximum number of nodes to build
+	}
+	timeout := opts.timeout // Set the timeout for graph building
+
+	slog.Info("Starting graph building", "seed", seedConcept)     // Log the start of graph building
+	err = graphBuilder.BuildGraph(seedConcept, maxNodes, timeout) // Build the graph
+	if err != nil {
+		slog.Warn("Graph building stopped", "error", err) // Log any errors during graph building
 	}
 
 	// Add a small delay to allow for graph building
 	time.Sleep(5 * time.Second) // Sleep for 5 seconds
 
-	log.Println("Starting random relationship mining") // Log the start of random relationship mining
-	graphBuilder.MineRandomRelationships(50, 5)        // Mine 50 random relationships with 5 concurrent goroutines
+	if opts.mine > 0 {
+		slog.Info("Starting random relationship mining")                  // Log the start of random relationship mining
+		graphBuilder.MineRandomRelationships(opts.mine, opts.mineWorkers) // Mine random relationships with concurrent goroutines
+	}
+
+	for _, q := range neo4j.SlowestQueries() { // Summarize the slowest queries of the run
+		slog.Info("Slowest Neo4j query", "duration", q.Duration.Round(time.Millisecond), "rows", q.Rows, "query", q.Query)
+	}
+
+	if llmClient != nil {
+		slog.Info("LLM cache statistics", "stats", llmClient.CacheStats().String()) // Log how many LLM calls the cache saved
+		if err := llmClient.Close(); err != nil {                                   // Persist the cache statistics for "kg-builder cache stats"
+			slog.Warn("Failed to save LLM cache statistics", "error", err)
+		}
+	}
+
+	slog.Info("Knowledge Graph Builder completed successfully") // Log successful completion of the application
+}
 
-	log.Println("Knowledge Graph Builder completed successfully") // Log successful completion of the application
+// fatal logs the error and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// applyNeo4jSettings applies the configuration of the neo4j package shared by the build and the maintenance commands.
+func applyNeo4jSettings(cfg *config.Config) {
+	neo4j.SetReadOnly(cfg.Graph.ReadOnly)
+	neo4j.SetRelationAllowlist(cfg.Graph.RelationAllowlist)
+	neo4j.SetSlowQueryThreshold(cfg.Graph.SlowQueryThreshold)
+	if err := neo4j.SetRelationStrategy(cfg.Graph.RelationStrategy); err != nil {
+		log.Fatalf("Invalid relation strategy: %v", err) // Already validated by config.Load
+	}
 }
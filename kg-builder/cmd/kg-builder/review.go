@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"kg-builder/internal/screen"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newReviewCommand returns "kg-builder review", whose subcommands operate on GRAPH_REVIEW_FILE, the queue of
+// relationships held back by GRAPH_SCREEN_DETECTORS and GRAPH_SCREEN_WORDLIST.
+func newReviewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "Approve or reject relationships held back by screening",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the relationships held back by screening",
+		Args:  cobra.NoArgs,
+		RunE: withReviewQueue(func(queue *screen.Queue, ids []int) error {
+			return reviewList(queue)
+		}),
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "approve ID...",
+		Short: "Write the relationships to the graph and take them off the queue",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  withReviewQueue(reviewApprove),
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "reject ID...",
+		Short: "Take the relationships off the queue without writing them",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  withReviewQueue(reviewReject),
+	})
+	return cmd
+}
+
+// withReviewQueue is withConfig for the review subcommands, which are given the queue and the item IDs of their
+// arguments.
+func withReviewQueue(run func(queue *screen.Queue, ids []int) error) func(*cobra.Command, []string) error {
+	return withConfig(func(cfg *config.Config, args []string) error {
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid review item ID %q", arg)
+			}
+			ids[i] = id
+		}
+		queue, err := screen.OpenQueue(cfg.Graph.ReviewFile)
+		if err != nil {
+			return err
+		}
+		return run(queue, ids)
+	})
+}
+
+func reviewList(queue *screen.Queue) error {
+	items, err := queue.Items()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFLAGGED\tFROM\tRELATION\tTO\tREASON")
+	for _, i := range items {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i.ID, i.FlaggedAt.Format(time.RFC3339), i.From, i.Relation, i.To, i.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d relationships awaiting review\n", len(items))
+	return nil
+}
+
+// reviewApprove writes the items to the graph before taking them off the queue, so a failed write loses nothing.
+func reviewApprove(queue *screen.Queue, ids []int) error {
+	items, err := queue.Items()
+	if err != nil {
+		return err
+	}
+	approve := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		approve[id] = true
+	}
+	var relationships []neo4j.Relationship
+	var approved []int
+	for _, i := range items {
+		if approve[i.ID] {
+			relationships = append(relationships, neo4j.Relationship{From: i.From, To: i.To, Type: i.Relation})
+			approved = append(approved, i.ID)
+		}
+	}
+	if len(approved) < len(ids) {
+		return fmt.Errorf("%d of the IDs are not in the review queue", len(ids)-len(approved))
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+	if err := neo4j.CreateRelationshipsBatch(driver, relationships); err != nil {
+		return fmt.Errorf("failed to write the approved relationships: %w", err)
+	}
+	if _, err := queue.Remove(approved); err != nil {
+		return err
+	}
+	log.Printf("Approved %d relationships", len(approved))
+	return nil
+}
+
+func reviewReject(queue *screen.Queue, ids []int) error {
+	removed, err := queue.Remove(ids)
+	if err != nil {
+		return err
+	}
+	if len(removed) < len(ids) {
+		log.Printf("%d of the IDs were not in the review queue", len(ids)-len(removed))
+	}
+	log.Printf("Rejected %d relationships", len(removed))
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"kg-builder/internal/config"
+	"kg-builder/internal/llm"
+	"kg-builder/internal/neo4j"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newIndexesCommand returns "kg-builder indexes", which reports the indexes the builder's queries need and optionally
+// creates the missing ones.
+func newIndexesCommand() *cobra.Command {
+	var create bool
+	cmd := &cobra.Command{
+		Use:   "indexes",
+		Short: "Recommend and create the indexes the queries need",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runIndexesCommand(create)
+		}),
+	}
+	cmd.Flags().BoolVar(&create, "create", false, "Create the missing indexes")
+	return cmd
+}
+
+func runIndexesCommand(create bool) error {
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	advice, err := neo4j.AdviseIndexes(driver)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTITY\tPROPERTY\tSTATUS\tREASON")
+	var missing []neo4j.IndexAdvice
+	for _, a := range advice {
+		status := "ok"
+		if !a.Exists {
+			status = "missing"
+			missing = append(missing, a)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Entity, a.Property, status, a.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	if !create {
+		fmt.Println("\nCreate the missing indexes with kg-builder indexes --create, or run:")
+		for _, a := range missing {
+			fmt.Printf("  %s;\n", a.Statement)
+		}
+		return nil
+	}
+	for _, a := range missing {
+		if err := neo4j.CreateIndex(driver, a); err != nil {
+			return err
+		}
+		log.Printf("Created index on %s.%s", a.Entity, a.Property)
+	}
+	return nil
+}
+
+// newMigrateRelationsCommand returns "kg-builder migrate-relations", which converts the relationships stored with the
+// other relation strategy to GRAPH_RELATION_STRATEGY.
+func newMigrateRelationsCommand() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate-relations",
+		Short: "Convert relationships to GRAPH_RELATION_STRATEGY",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runMigrateRelationsCommand(cfg, dryRun)
+		}),
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only report the relationships that would be converted")
+	return cmd
+}
+
+func runMigrateRelationsCommand(cfg *config.Config, dryRun bool) error {
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	counts, err := neo4j.RelationsToMigrate(driver)
+	if err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		log.Printf("All relationships already use the %s strategy", cfg.Graph.RelationStrategy)
+		return nil
+	}
+
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RELATION\tRELATIONSHIPS")
+	for _, t := range types {
+		fmt.Fprintf(w, "%s\t%d\n", t, counts[t])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	migrated, err := neo4j.MigrateRelations(driver)
+	if err != nil {
+		return err
+	}
+	log.Printf("Converted %d relationships to the %s strategy", migrated, cfg.Graph.RelationStrategy)
+	return nil
+}
+
+// renameRelationsOptions are the flags of "kg-builder rename-relations".
+type renameRelationsOptions struct {
+	dryRun  bool
+	history bool
+}
+
+// newRenameRelationsCommand returns "kg-builder rename-relations", which renames or merges relation types in the graph
+// and in the cached LLM answers.
+func newRenameRelationsCommand() *cobra.Command {
+	var opts renameRelationsOptions
+	cmd := &cobra.Command{
+		Use:   "rename-relations OLD=NEW...",
+		Short: "Rename or merge relation types",
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runRenameRelationsCommand(cfg, args, opts)
+		}),
+	}
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only report the relationships that would be renamed")
+	cmd.Flags().BoolVar(&opts.history, "history", false, "List the renames applied so far")
+	return cmd
+}
+
+func runRenameRelationsCommand(cfg *config.Config, args []string, opts renameRelationsOptions) error {
+	if !opts.history && len(args) == 0 {
+		return fmt.Errorf("give at least one OLD=NEW rename, or --history")
+	}
+
+	mapping := make(map[string]string, len(args))
+	for _, arg := range args {
+		old, to, ok := strings.Cut(arg, "=")
+		if !ok || old == "" || to == "" {
+			return fmt.Errorf("invalid rename %q, expected OLD=NEW", arg)
+		}
+		mapping[old] = neo4j.SanitizeRelationType(to)
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	if opts.history {
+		renames, err := neo4j.RelationRenames(driver)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "APPLIED\tFROM\tTO\tRELATIONSHIPS")
+		for _, r := range renames {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", r.AppliedAt.Format(time.RFC3339), r.From, r.To, r.Relationships)
+		}
+		return w.Flush()
+	}
+
+	olds := make([]string, 0, len(mapping))
+	for old := range mapping {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tRELATIONSHIPS")
+	for _, old := range olds {
+		count, err := neo4j.CountRelationType(driver, old)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", old, mapping[old], count)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if opts.dryRun {
+		return nil
+	}
+
+	renamed, err := neo4j.RenameRelations(driver, mapping)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, n := range renamed {
+		total += n
+	}
+	log.Printf("Renamed %d relationships", total)
+
+	// Cached answers keep the LLM's raw relation text, so they are matched on the sanitized type
+	sanitized := make(map[string]string, len(mapping))
+	for old, to := range mapping {
+		sanitized[neo4j.SanitizeRelationType(old)] = to
+	}
+	entries, err := llm.RenameCachedRelations(cfg.LLM.CacheDir, func(relation string) (string, bool) {
+		to, ok := sanitized[neo4j.SanitizeRelationType(relation)]
+		return to, ok
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // No cache to update
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update the cached answers: %w", err)
+	}
+	log.Printf("Updated %d cached answers", entries)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"kg-builder/internal/sample"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// newSeedSampleCommand returns "kg-builder seed-sample", which loads the bundled sample graph into an empty database.
+func newSeedSampleCommand() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "seed-sample",
+		Short: "Load the bundled sample graph instead of building one",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runSeedSampleCommand(cfg, force)
+		}),
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Load the sample even if the database already contains concepts")
+	return cmd
+}
+
+func runSeedSampleCommand(cfg *config.Config, force bool) error {
+	relationships, err := sample.Relationships()
+	if err != nil {
+		return err
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	count, err := neo4j.CountConcepts(driver)
+	if err != nil {
+		return fmt.Errorf("failed to count concepts: %w", err)
+	}
+	if count > 0 && !force {
+		return fmt.Errorf("database already contains %d concepts; use --force to load the sample anyway", count)
+	}
+
+	batch := make([]neo4j.Relationship, 0, len(relationships))
+	for _, r := range relationships {
+		batch = append(batch, neo4j.Relationship{From: r.RelatedTo, To: r.Name, Type: r.Relation})
+	}
+	if err := neo4j.CreateRelationshipsBatch(driver, batch); err != nil {
+		return fmt.Errorf("failed to create the sample relationships: %w", err)
+	}
+
+	log.Printf("Loaded %d sample relationships", len(relationships))
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// statsOptions are the flags of "kg-builder stats".
+type statsOptions struct {
+	top    int
+	asJSON bool
+}
+
+// newStatsCommand returns "kg-builder stats", which reports the size and shape of the graph: counts, relation types,
+// degree distribution and the most connected concepts.
+func newStatsCommand() *cobra.Command {
+	var opts statsOptions
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report the counts, relation types, degree distribution and hubs of the graph",
+		Args:  cobra.NoArgs,
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runStatsCommand(cfg, opts)
+		}),
+	}
+	cmd.Flags().IntVar(&opts.top, "top", 10, "Number of most connected concepts listed")
+	cmd.Flags().BoolVar(&opts.asJSON, "json", false, "Print the statistics as JSON")
+	return cmd
+}
+
+func runStatsCommand(cfg *config.Config, opts statsOptions) error {
+	if opts.top < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	stats, err := neo4j.GetExtendedStats(driver, opts.top)
+	if err != nil {
+		return err
+	}
+	if opts.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	return printStats(os.Stdout, stats)
+}
+
+func printStats(out io.Writer, stats *neo4j.ExtendedStats) error {
+	fmt.Fprintf(out, "Concepts:       %d (%d without relationships)\n", stats.Concepts, stats.Orphans)
+	fmt.Fprintf(out, "Relationships:  %d\n", stats.Relationships)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nRELATION TYPE\tCOUNT\tSHARE")
+	types := make([]string, 0, len(stats.RelationTypes))
+	for t := range stats.RelationTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if stats.RelationTypes[types[i]] != stats.RelationTypes[types[j]] {
+			return stats.RelationTypes[types[i]] > stats.RelationTypes[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	for _, t := range types {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", t, stats.RelationTypes[t], percent(stats.RelationTypes[t], stats.Relationships))
+	}
+
+	fmt.Fprintln(w, "\nDEGREE\tCONCEPTS\tSHARE")
+	for _, b := range stats.Degrees {
+		degree := fmt.Sprintf("%d-%d", b.Min, b.Max)
+		switch {
+		case b.Max < 0:
+			degree = fmt.Sprintf("%d+", b.Min)
+		case b.Min == b.Max:
+			degree = fmt.Sprint(b.Min)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", degree, b.Concepts, percent(b.Concepts, stats.Concepts))
+	}
+
+	if len(stats.TopConcepts) > 0 {
+		fmt.Fprintln(w, "\nCONCEPT\tDEGREE")
+		for _, c := range stats.TopConcepts {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Degree)
+		}
+	}
+	return w.Flush()
+}
+
+// percent returns n as a percentage of total, or 0 if total is 0.
+func percent(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
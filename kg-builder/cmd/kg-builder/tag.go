@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// newTagCommand returns "kg-builder tag", whose subcommands manage free-form concept tags.
+func newTagCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Tag concepts",
+	}
+	cmd.AddCommand(newTagChangeCommand("add", "Add the tag to the concepts, or to the concepts matching the rules"))
+	cmd.AddCommand(newTagChangeCommand("remove",
+		"Remove the tag from the concepts, or from the concepts matching the rules"))
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list [TAG]",
+		Short: "List the tags with their concept counts, or the concepts carrying TAG",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runTagList(args)
+		}),
+	})
+	return cmd
+}
+
+// newTagChangeCommand returns "tag add" or "tag remove", which apply the change to the given concepts or, when only
+// the tag is given, to the concepts selected by the rules.
+func newTagChangeCommand(name, short string) *cobra.Command {
+	var opts tagRuleOptions
+	cmd := &cobra.Command{
+		Use:   name + " TAG [CONCEPT...]",
+		Short: short,
+		Long: short + ".\n\nThe rules are combined with AND and are used when no concepts are given; " +
+			"at least one is required then.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			rules, err := opts.rules()
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 && !rules.set() {
+				return fmt.Errorf("give the concepts to %s the tag or rules selecting them", name)
+			}
+			if len(args) > 1 && rules.set() {
+				return fmt.Errorf("give either concepts or rules, not both")
+			}
+
+			driver, err := neo4j.SetupNeo4jConnection()
+			if err != nil {
+				return fmt.Errorf("failed to connect to Neo4j: %w", err)
+			}
+			defer driver.Close()
+
+			tag, names := args[0], args[1:]
+			if rules.set() {
+				degrees, err := neo4j.ConceptDegrees(driver)
+				if err != nil {
+					return fmt.Errorf("failed to get concept degrees: %w", err)
+				}
+				names = rules.filter(degrees)
+				if rules.dryRun {
+					for _, name := range names {
+						fmt.Println(name)
+					}
+					fmt.Printf("\n%d concepts match the rules\n", len(names))
+					return nil
+				}
+			}
+			if name == "add" {
+				changed, err := neo4j.TagConcepts(driver, tag, names)
+				if err != nil {
+					return err
+				}
+				log.Printf("Tagged %d concepts with %s", changed, tag)
+				return nil
+			}
+			changed, err := neo4j.UntagConcepts(driver, tag, names)
+			if err != nil {
+				return err
+			}
+			log.Printf("Removed %s from %d concepts", tag, changed)
+			return nil
+		}),
+	}
+	cmd.Flags().StringVar(&opts.match, "match", "",
+		`Regular expression the concept name must match, e.g. "(?i)learning$"`)
+	cmd.Flags().Int64Var(&opts.minDegree, "min-degree", 0, "Minimum number of relationships")
+	cmd.Flags().Int64Var(&opts.maxDegree, "max-degree", -1, "Maximum number of relationships (-1 for no maximum)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only list the concepts the rules select")
+	return cmd
+}
+
+// runTagList lists the tags with their concept counts, or the concepts carrying the tag given as argument.
+func runTagList(args []string) error {
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	if len(args) == 1 {
+		names, err := neo4j.ConceptsWithTag(driver, args[0])
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+	counts, err := neo4j.TagCounts(driver)
+	if err != nil {
+		return err
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tCONCEPTS")
+	for _, tag := range tags {
+		fmt.Fprintf(w, "%s\t%d\n", tag, counts[tag])
+	}
+	return w.Flush()
+}
+
+// tagRuleOptions are the rule flags of "tag add" and "tag remove".
+type tagRuleOptions struct {
+	match     string
+	minDegree int64
+	maxDegree int64
+	dryRun    bool
+}
+
+// tagRules select the concepts a bulk tag change applies to.
+type tagRules struct {
+	match     *regexp.Regexp
+	minDegree int64
+	maxDegree int64 // Negative means no maximum
+	dryRun    bool
+}
+
+// rules compiles the rule flags.
+func (o tagRuleOptions) rules() (tagRules, error) {
+	rules := tagRules{minDegree: o.minDegree, maxDegree: o.maxDegree, dryRun: o.dryRun}
+	if o.match != "" {
+		re, err := regexp.Compile(o.match)
+		if err != nil {
+			return tagRules{}, fmt.Errorf("invalid --match: %w", err)
+		}
+		rules.match = re
+	}
+	return rules, nil
+}
+
+// set reports whether any selection rule was given.
+func (r tagRules) set() bool {
+	return r.match != nil || r.minDegree > 0 || r.maxDegree >= 0
+}
+
+// filter returns the names of the concepts matching all rules.
+func (r tagRules) filter(degrees []neo4j.ConceptDegree) []string {
+	var names []string
+	for _, d := range degrees {
+		if r.match != nil && !r.match.MatchString(d.Name) {
+			continue
+		}
+		if d.Degree < r.minDegree || (r.maxDegree >= 0 && d.Degree > r.maxDegree) {
+			continue
+		}
+		names = append(names, d.Name)
+	}
+	return names
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"kg-builder/internal/config"
+	"kg-builder/internal/neo4j"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newTimelineCommand returns "kg-builder timeline CONCEPT", which lists the creation, relationships, merges, splits
+// and removed relationships of a concept in the order they happened.
+func newTimelineCommand() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "timeline CONCEPT",
+		Short: "List what the graph learned about a concept over time",
+		Args:  cobra.ExactArgs(1),
+		RunE: withConfig(func(cfg *config.Config, args []string) error {
+			return runTimelineCommand(cfg, args[0], asJSON)
+		}),
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the timeline as JSON")
+	return cmd
+}
+
+func runTimelineCommand(cfg *config.Config, concept string, asJSON bool) error {
+	driver, err := neo4j.SetupNeo4jConnection()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	defer driver.Close()
+
+	events, err := neo4j.ConceptTimeline(driver, concept)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(events)
+	}
+	return printTimeline(os.Stdout, concept, events)
+}
+
+func printTimeline(out io.Writer, concept string, events []neo4j.TimelineEvent) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tRUN\tEVENT")
+	for _, e := range events {
+		at := "unknown"
+		if !e.At.IsZero() {
+			at = e.At.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", at, e.RunID, describeEvent(concept, e))
+	}
+	return w.Flush()
+}
+
+// describeEvent returns a one-line description of a timeline event of the concept.
+func describeEvent(concept string, e neo4j.TimelineEvent) string {
+	switch e.Kind {
+	case neo4j.TimelineCreated:
+		return "created"
+	case neo4j.TimelineMerged:
+		return "merged " + strings.Join(e.Concepts, ", ")
+	case neo4j.TimelineSplit:
+		return "split from " + strings.Join(e.Concepts, ", ")
+	}
+	r := e.Relationship
+	description := fmt.Sprintf("<-[%s]- %s", r.Type, r.From)
+	if r.From == concept {
+		description = fmt.Sprintf("-[%s]-> %s", r.Type, r.To)
+	}
+	if e.Kind == neo4j.TimelineRemoved {
+		return fmt.Sprintf("removed %s (%s)", description, e.Reason)
+	}
+	return description
+}
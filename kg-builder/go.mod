@@ -1,5 +1,14 @@
 module kg-builder
 
-go 1.20
+go 1.21
 
-require github.com/neo4j/neo4j-go-driver/v4 v4.4.7
+require (
+	github.com/neo4j/neo4j-go-driver/v4 v4.4.7
+	github.com/spf13/cobra v1.8.0
+	golang.org/x/sync v0.6.0
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+)
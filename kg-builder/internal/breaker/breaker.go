@@ -0,0 +1,145 @@
+// Package breaker stops calls to a failing dependency so callers do not keep hammering it while it is down.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kg-builder/internal/logging"
+)
+
+var logger = logging.For("breaker")
+
+// ErrOpen is returned, wrapped, for calls refused because the breaker is open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// States of a breaker.
+const (
+	Closed   = "closed"    // Calls go through
+	Open     = "open"      // Calls are refused until the cooldown is over
+	HalfOpen = "half-open" // One probe call goes through; its outcome closes or reopens the breaker
+)
+
+// Stats describes a breaker.
+type Stats struct {
+	Name                string `json:"name"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Trips               int    `json:"trips"` // Times the breaker opened
+}
+
+// Breaker opens after a number of consecutive failures and refuses calls for a cooldown. It then lets one probe
+// call through: success closes it, failure opens it for another cooldown. A nil Breaker lets every call through.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	mutex     sync.Mutex
+	state     string
+	failures  int
+	openUntil time.Time
+	trips     int
+}
+
+// New returns a breaker opening after threshold consecutive failures, or nil if threshold is 0.
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &Breaker{name: name, threshold: threshold, cooldown: cooldown, state: Closed}
+}
+
+// Allow reports whether a call may be made, returning an error wrapping ErrOpen if not. Every allowed call must be
+// followed by Record with its outcome.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch {
+	case b.state == Closed:
+		return nil
+	case b.state == Open && !time.Now().Before(b.openUntil):
+		b.state = HalfOpen // This call is the probe
+		logger.Info("Circuit breaker half-open, probing", "name", b.name)
+		return nil
+	case b.state == Open:
+		return fmt.Errorf("%s: %w until %s", b.name, ErrOpen, b.openUntil.Format(time.TimeOnly))
+	default:
+		return fmt.Errorf("%s: %w, probe in progress", b.name, ErrOpen)
+	}
+}
+
+// Record reports the outcome of an allowed call.
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err == nil {
+		if b.state != Closed {
+			logger.Info("Circuit breaker closed", "name", b.name)
+		}
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		if b.state == Closed {
+			b.trips++
+		}
+		b.state = Open
+		b.openUntil = time.Now().Add(b.cooldown)
+		logger.Warn("Circuit breaker open", "name", b.name, "failures", b.failures, "cooldown", b.cooldown, "error", err)
+	}
+}
+
+// Wait blocks while calls would be refused, until the cooldown is over and no probe is in progress. It returns
+// false if ctx is done first.
+func (b *Breaker) Wait(ctx context.Context) bool {
+	for {
+		wait := b.retryIn()
+		if wait <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// retryIn returns how long until a call may be allowed, or 0 if it may be now.
+func (b *Breaker) retryIn() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch b.state {
+	case Open:
+		return time.Until(b.openUntil)
+	case HalfOpen:
+		return 100 * time.Millisecond // Poll until the probe ends
+	}
+	return 0
+}
+
+// Stats returns the state of the breaker.
+func (b *Breaker) Stats() Stats {
+	if b == nil {
+		return Stats{State: Closed}
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return Stats{Name: b.name, State: b.state, ConsecutiveFailures: b.failures, Trips: b.trips}
+}
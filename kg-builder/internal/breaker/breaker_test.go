@@ -0,0 +1,127 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+var errCall = errors.New("call failed")
+
+// call makes a call through the breaker, recording err as its outcome if it is allowed.
+func call(b *Breaker, err error) error {
+	if allowErr := b.Allow(); allowErr != nil {
+		return allowErr
+	}
+	b.Record(err)
+	return nil
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		calls     []error // Outcomes of the calls, in order
+		cooled    bool    // Wait for the cooldown after the calls
+		wantState string
+		wantTrips int
+		wantFails int
+	}{
+		{"closed after successes", []error{nil, nil}, false, Closed, 0, 0},
+		{"closed below the threshold", []error{errCall, errCall}, false, Closed, 0, 2},
+		{"success resets the failures", []error{errCall, errCall, nil, errCall}, false, Closed, 0, 1},
+		{"open at the threshold", []error{errCall, errCall, errCall}, false, Open, 1, 3},
+		{"open refuses calls", []error{errCall, errCall, errCall, nil}, false, Open, 1, 3},
+		{"cooled down open breaker stays open until probed", []error{errCall, errCall, errCall}, true, Open, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("test", 3, testCooldown)
+			for _, err := range tt.calls {
+				call(b, err)
+			}
+			if tt.cooled {
+				time.Sleep(testCooldown)
+			}
+			got := b.Stats()
+			if got.State != tt.wantState || got.Trips != tt.wantTrips || got.ConsecutiveFailures != tt.wantFails {
+				t.Errorf("Stats() = %+v, want state %s, %d trips and %d failures", got, tt.wantState, tt.wantTrips, tt.wantFails)
+			}
+		})
+	}
+}
+
+func TestBreakerProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     error
+		wantState string
+		wantTrips int
+	}{
+		{"successful probe closes", nil, Closed, 1},
+		{"failed probe reopens", errCall, Open, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("test", 1, testCooldown)
+			call(b, errCall)
+			if err := b.Allow(); !errors.Is(err, ErrOpen) {
+				t.Fatalf("Allow() error = %v, want ErrOpen", err)
+			}
+			time.Sleep(testCooldown)
+
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow() error = %v, want the probe allowed", err)
+			}
+			if got := b.Stats().State; got != HalfOpen {
+				t.Fatalf("state during the probe = %s, want %s", got, HalfOpen)
+			}
+			if err := b.Allow(); !errors.Is(err, ErrOpen) {
+				t.Errorf("Allow() during the probe error = %v, want ErrOpen", err)
+			}
+			b.Record(tt.probe)
+
+			got := b.Stats()
+			if got.State != tt.wantState || got.Trips != tt.wantTrips {
+				t.Errorf("Stats() = %+v, want state %s and %d trips", got, tt.wantState, tt.wantTrips)
+			}
+		})
+	}
+}
+
+func TestNilBreaker(t *testing.T) {
+	b := New("test", 0, testCooldown)
+	if b != nil {
+		t.Fatalf("New() with threshold 0 = %+v, want nil", b)
+	}
+	for i := 0; i < 3; i++ {
+		if err := call(b, errCall); err != nil {
+			t.Fatalf("call through a nil breaker error = %v", err)
+		}
+	}
+	if got := b.Stats(); got.State != Closed {
+		t.Errorf("Stats() = %+v, want closed", got)
+	}
+	if !b.Wait(context.Background()) {
+		t.Error("Wait() on a nil breaker = false")
+	}
+}
+
+func TestBreakerWait(t *testing.T) {
+	b := New("test", 1, testCooldown)
+	call(b, errCall)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if b.Wait(ctx) {
+		t.Error("Wait() with a done context on an open breaker = true")
+	}
+	if !b.Wait(context.Background()) {
+		t.Error("Wait() = false, want true after the cooldown")
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() after Wait() error = %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Package cluster groups concepts by the similarity of their embeddings, for thematic exploration of the vocabulary.
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// maxIterations bounds the k-means iterations when the assignments keep changing.
+const maxIterations = 100
+
+// Cluster is a group of concepts with similar embeddings.
+type Cluster struct {
+	Label           string   `json:"label"`           // Most central concept
+	Size            int      `json:"size"`            // Number of concepts
+	Representatives []string `json:"representatives"` // Most central concepts, the label first
+	Concepts        []string `json:"concepts"`        // All concepts, the most central first
+}
+
+// KMeans groups the concepts into k clusters by cosine similarity of their vectors and returns the non-empty
+// clusters, largest first. The same seed yields the same clusters. Each cluster lists its top most central concepts
+// as representatives.
+func KMeans(names []string, vectors [][]float64, k, top int, seed int64) []Cluster {
+	if len(vectors) == 0 {
+		return nil
+	}
+	k = min(max(k, 1), len(vectors))
+	points := make([][]float64, len(vectors))
+	for i, v := range vectors {
+		points[i] = normalize(v)
+	}
+
+	centroids := initCentroids(points, k, rand.New(rand.NewSource(seed)))
+	assignments := make([]int, len(points))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		changed := false
+		for i, p := range points {
+			if c := nearest(p, centroids); c != assignments[i] {
+				assignments[i] = c
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		centroids = means(points, assignments, centroids)
+	}
+	return clusters(names, points, assignments, centroids, top)
+}
+
+// initCentroids picks k points with k-means++: each after the first is drawn with a probability growing with its
+// distance to the nearest centroid picked so far.
+func initCentroids(points [][]float64, k int, r *rand.Rand) [][]float64 {
+	centroids := [][]float64{points[r.Intn(len(points))]}
+	distances := make([]float64, len(points))
+	for len(centroids) < k {
+		total := 0.0
+		for i, p := range points {
+			d := 1 - dot(p, centroids[nearest(p, centroids)])
+			distances[i] = d * d
+			total += distances[i]
+		}
+		if total == 0 { // Fewer distinct points than clusters
+			break
+		}
+		target := r.Float64() * total
+		i := 0
+		for ; i < len(points)-1 && target >= distances[i]; i++ {
+			target -= distances[i]
+		}
+		centroids = append(centroids, points[i])
+	}
+	return centroids
+}
+
+// means returns the normalized mean of the points of each cluster. A cluster left without points keeps its
+// centroid.
+func means(points [][]float64, assignments []int, previous [][]float64) [][]float64 {
+	sums := make([][]float64, len(previous))
+	for c := range sums {
+		sums[c] = make([]float64, len(previous[c]))
+	}
+	counts := make([]int, len(previous))
+	for i, p := range points {
+		c := assignments[i]
+		counts[c]++
+		for j := range p {
+			if j < len(sums[c]) {
+				sums[c][j] += p[j]
+			}
+		}
+	}
+	for c := range sums {
+		if counts[c] == 0 {
+			sums[c] = previous[c]
+			continue
+		}
+		sums[c] = normalize(sums[c])
+	}
+	return sums
+}
+
+// clusters builds the clusters from the assignments, ordering the concepts of each by similarity to its centroid.
+func clusters(names []string, points [][]float64, assignments []int, centroids [][]float64, top int) []Cluster {
+	members := make([][]int, len(centroids))
+	for i, c := range assignments {
+		members[c] = append(members[c], i)
+	}
+
+	var result []Cluster
+	for c, indexes := range members {
+		if len(indexes) == 0 {
+			continue
+		}
+		similarity := make(map[int]float64, len(indexes))
+		for _, i := range indexes {
+			similarity[i] = dot(points[i], centroids[c])
+		}
+		sort.SliceStable(indexes, func(a, b int) bool { return similarity[indexes[a]] > similarity[indexes[b]] })
+
+		cluster := Cluster{Size: len(indexes)}
+		for _, i := range indexes {
+			cluster.Concepts = append(cluster.Concepts, names[i])
+		}
+		cluster.Label = cluster.Concepts[0]
+		cluster.Representatives = cluster.Concepts[:min(max(top, 1), len(cluster.Concepts))]
+		result = append(result, cluster)
+	}
+	sort.SliceStable(result, func(a, b int) bool { return result[a].Size > result[b].Size })
+	return result
+}
+
+// nearest returns the index of the centroid most similar to the point.
+func nearest(p []float64, centroids [][]float64) int {
+	best, bestSimilarity := 0, math.Inf(-1)
+	for c, centroid := range centroids {
+		if s := dot(p, centroid); s > bestSimilarity {
+			best, bestSimilarity = c, s
+		}
+	}
+	return best
+}
+
+// normalize returns v scaled to unit length, so the dot product of two normalized vectors is their cosine
+// similarity.
+func normalize(v []float64) []float64 {
+	norm := math.Sqrt(dot(v, v))
+	n := make([]float64, len(v))
+	if norm == 0 {
+		return n
+	}
+	for i, x := range v {
+		n[i] = x / norm
+	}
+	return n
+}
+
+func dot(a, b []float64) float64 {
+	s := 0.0
+	for i := 0; i < len(a) && i < len(b); i++ {
+		s += a[i] * b[i]
+	}
+	return s
+}
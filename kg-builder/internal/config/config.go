@@ -0,0 +1,498 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kg-builder/internal/logging"
+)
+
+// Config holds the runtime configuration of the knowledge graph builder.
+type Config struct {
+	LLM   LLMConfig
+	Graph GraphConfig
+	Log   LogConfig
+}
+
+// LogConfig holds the logging settings, loaded on their own by LoadLog so logging can be set up before the rest.
+type LogConfig struct {
+	Level  string // Default level, optionally followed by module=level pairs, e.g. "info,graph=debug,neo4j=warn"
+	Format string // "text" or "json"
+}
+
+// LLMConfig holds the settings used to talk to the Ollama service.
+type LLMConfig struct {
+	Provider string // "ollama", or "synthetic" for a generated ontology standing in for the LLM
+
+	URLs        []string      // Base URLs of the Ollama APIs requests are balanced across, e.g. http://localhost:11434
+	Balancing   string        // How requests are spread over URLs: "round-robin" or "least-loaded"
+	Cooldown    time.Duration // How long an endpoint that failed is skipped before it is tried again
+	Model       string        // Model used for generation
+	AutoPull    bool          // Pull the model before building if it is not present
+	PullTimeout time.Duration // Maximum time to wait for a model pull
+
+	// Limits on the generate requests over all endpoints, for hosted APIs that throttle clients
+	RateLimit       int // Maximum requests per minute (0 is unlimited)
+	MaxInFlight     int // Maximum requests in flight (0 is unlimited)
+	ThrottleRetries int // Times a throttled request (429, or 503 with Retry-After) is retried after the wait asked for
+
+	BreakerThreshold int           // Consecutive failed requests opening the circuit breaker (0 disables it)
+	BreakerCooldown  time.Duration // How long the open breaker refuses requests before letting a probe through
+
+	// Per-task models, defaulting to Model, so cheap bulk tasks can use a small model and precision tasks a larger one
+	ExpansionModel string // Model expanding concepts into related concepts
+	MiningModel    string // Model deciding whether two concepts are related
+	CurationModel  string // Model assisting curation, e.g. suggesting how to split a concept
+	EmbeddingModel string // Model embedding concept names for "kg-builder cluster"
+
+	// Ollama options; zero values leave the Ollama defaults in place
+	KeepAlive  string // How long Ollama keeps a model loaded after a request, e.g. "30m" or "-1" for ever
+	NumCtx     int    // Context window size in tokens
+	NumPredict int    // Maximum number of tokens to generate
+	WarmUp     bool   // Load the models with an empty request before the build starts
+
+	RelatedCount     int      // Number of related concepts requested per expansion prompt
+	RelationFamilies []string // Relation families to issue targeted expansion prompts for; empty uses one generic prompt
+	PromptsFile      string   // Optional JSON file overriding or adding relation family prompts
+	ExamplesFile     string   // Optional JSON file of few-shot examples by domain
+	ExamplesDomain   string   // Domain of the examples file used by this run
+
+	SyntheticSize int   // Number of concepts in the synthetic ontology
+	SyntheticSeed int64 // Seed of the synthetic ontology; the same seed yields the same ontology
+
+	CacheEnabled     bool          // Cache LLM answers between runs
+	CacheBackend     string        // Where answers are cached: "file" (CacheDir), "memory" (this run only) or "redis"
+	CacheDir         string        // Directory holding the cache entries
+	CacheRedisURL    string        // Redis server shared by the builders, e.g. redis://:password@redis:6379/0
+	CacheMaxSize     int64         // Size in bytes above which the oldest entries are evicted (0 is unlimited)
+	CacheNamespace   string        // Partition of the cache used by this run
+	CacheTTL         time.Duration // Lifetime of cached answers
+	CacheNegativeTTL time.Duration // Lifetime of cached "no relationship" answers
+}
+
+// GraphConfig holds the settings of the graph builder.
+type GraphConfig struct {
+	MaxNodes int // Maximum number of concepts a build adds
+	MaxDepth int // Maximum distance in relationships from the seed; concepts at it are added but not expanded (0 is unlimited)
+
+	DiversityThreshold float64  // Reject related concepts at least this similar to an existing neighbor (0 disables)
+	StopConditions     []string // Optional stop conditions: novelty, budget, frontier-empty
+	MinNovelty         float64  // Novelty rate below which the novelty condition stops the build
+	NoveltyWindow      int      // Number of recent expansions the novelty rate is computed over
+	LLMBudget          int      // Maximum number of expansion calls for the budget condition
+
+	MinExpansionQuality float64 // Expand the concepts of expansions scoring below this last; zero disables quality scoring
+
+	ConceptFilters   []string // Kinds of related concepts to reject: numbers, dates, single-letters, units
+	StopConceptsFile string   // Optional file of related concepts to reject, one per line
+
+	ScreenDetectors []string // Detectors of personal data that hold relationships back for review: email, phone
+	ScreenWordlist  string   // Optional file of offensive words and phrases that hold relationships back for review
+	ReviewFile      string   // Review queue of the relationships held back by screening
+
+	StallTimeout  time.Duration // Act when no relationship was created for this long; zero disables the watchdog
+	StallAction   string        // What to do on a stall: stop, restart or alert
+	StallRestarts int           // Worker restarts before the restart action stops the build
+	StallWebhook  string        // URL the stall report is posted to as JSON; empty disables it
+
+	DriftWindow    int     // Number of recent LLM outputs the acceptance rates are computed over; zero disables drift alerts
+	DriftThreshold float64 // Alert when an acceptance rate drops more than this below its baseline
+	DriftWebhook   string  // URL drift alerts are posted to as JSON; empty disables it
+
+	OutageBufferSize    int           // Maximum number of relationships buffered while Neo4j is unavailable
+	OutageRetryInterval time.Duration // How often connectivity is checked during an outage
+
+	Neo4jBreakerThreshold int           // Consecutive failed writes opening the Neo4j circuit breaker (0 disables it)
+	Neo4jBreakerCooldown  time.Duration // How long the open breaker refuses writes before letting a probe through
+
+	WALPath string // Write-ahead log of relationships awaiting commit; empty disables it
+
+	RelationAllowlist []string // Relation types allowed in the graph; empty allows every sanitized type
+	RelationStrategy  string   // How relations are stored: "property" (RELATED_TO with a type property) or "type"
+
+	MineTag string // Limit random relationship mining to the concepts carrying this tag; empty mines among the processed concepts
+
+	ReadOnly bool // Refuse every write to the graph while reads continue
+
+	SlowQueryThreshold time.Duration // Log Neo4j queries taking at least this long; zero disables it
+}
+
+// stopConditions are the valid values of GRAPH_STOP_CONDITIONS.
+var stopConditions = map[string]bool{"novelty": true, "budget": true, "frontier-empty": true}
+
+// conceptFilters are the valid values of GRAPH_CONCEPT_FILTERS.
+var conceptFilters = map[string]bool{"numbers": true, "dates": true, "single-letters": true, "units": true}
+
+// screenDetectors are the valid values of GRAPH_SCREEN_DETECTORS.
+var screenDetectors = map[string]bool{"email": true, "phone": true}
+
+// Load reads the configuration from environment variables, falling back to defaults.
+func Load() (*Config, error) {
+	var err error
+	cfg := &Config{
+		LLM: LLMConfig{
+			Model:          getEnv("LLM_MODEL", "llama3.1:latest"),
+			CacheDir:       getEnv("LLM_CACHE_DIR", "cache"),
+			CacheNamespace: getEnv("LLM_CACHE_NAMESPACE", "default"),
+		},
+	}
+
+	for _, url := range strings.Split(getEnv("LLM_URL", "http://host.docker.internal:11434"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			cfg.LLM.URLs = append(cfg.LLM.URLs, strings.TrimSuffix(strings.TrimSuffix(url, "/api/generate"), "/"))
+		}
+	}
+	if len(cfg.LLM.URLs) == 0 {
+		return nil, fmt.Errorf("invalid LLM_URL: no URL given")
+	}
+	cfg.LLM.Balancing = getEnv("LLM_BALANCING", "least-loaded")
+	if cfg.LLM.Balancing != "round-robin" && cfg.LLM.Balancing != "least-loaded" {
+		return nil, fmt.Errorf("invalid LLM_BALANCING: must be round-robin or least-loaded")
+	}
+	if cfg.LLM.Cooldown, err = getEnvDuration("LLM_ENDPOINT_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.RateLimit, err = getEnvInt("LLM_RATE_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.RateLimit < 0 {
+		return nil, fmt.Errorf("invalid LLM_RATE_LIMIT: must not be negative")
+	}
+	if cfg.LLM.MaxInFlight, err = getEnvInt("LLM_MAX_IN_FLIGHT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.MaxInFlight < 0 {
+		return nil, fmt.Errorf("invalid LLM_MAX_IN_FLIGHT: must not be negative")
+	}
+	if cfg.LLM.ThrottleRetries, err = getEnvInt("LLM_THROTTLE_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.ThrottleRetries < 0 {
+		return nil, fmt.Errorf("invalid LLM_THROTTLE_RETRIES: must not be negative")
+	}
+	if cfg.LLM.BreakerThreshold, err = getEnvInt("LLM_BREAKER_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.BreakerThreshold < 0 {
+		return nil, fmt.Errorf("invalid LLM_BREAKER_THRESHOLD: must not be negative")
+	}
+	if cfg.LLM.BreakerCooldown, err = getEnvDuration("LLM_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.BreakerCooldown <= 0 {
+		return nil, fmt.Errorf("invalid LLM_BREAKER_COOLDOWN: must be positive")
+	}
+	cfg.LLM.Provider = getEnv("LLM_PROVIDER", "ollama")
+	if cfg.LLM.Provider != "ollama" && cfg.LLM.Provider != "synthetic" {
+		return nil, fmt.Errorf("invalid LLM_PROVIDER: must be ollama or synthetic")
+	}
+	if cfg.LLM.SyntheticSize, err = getEnvInt("LLM_SYNTHETIC_SIZE", 10000); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.SyntheticSize <= 0 {
+		return nil, fmt.Errorf("invalid LLM_SYNTHETIC_SIZE: must be positive")
+	}
+	seed, err := getEnvInt("LLM_SYNTHETIC_SEED", 1)
+	if err != nil {
+		return nil, err
+	}
+	cfg.LLM.SyntheticSeed = int64(seed)
+
+	cfg.LLM.ExpansionModel = getEnv("LLM_EXPANSION_MODEL", cfg.LLM.Model)
+	cfg.LLM.MiningModel = getEnv("LLM_MINING_MODEL", cfg.LLM.Model)
+	cfg.LLM.CurationModel = getEnv("LLM_CURATION_MODEL", cfg.LLM.Model)
+	cfg.LLM.EmbeddingModel = getEnv("LLM_EMBEDDING_MODEL", "nomic-embed-text")
+
+	cfg.LLM.KeepAlive = os.Getenv("LLM_KEEP_ALIVE")
+	if cfg.LLM.KeepAlive != "" {
+		if _, err := strconv.Atoi(cfg.LLM.KeepAlive); err != nil {
+			if _, err := time.ParseDuration(cfg.LLM.KeepAlive); err != nil {
+				return nil, fmt.Errorf("invalid LLM_KEEP_ALIVE: must be a duration such as 30m or a number of seconds")
+			}
+		}
+	}
+	if cfg.LLM.NumCtx, err = getEnvInt("LLM_NUM_CTX", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.NumPredict, err = getEnvInt("LLM_NUM_PREDICT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.NumCtx < 0 || cfg.LLM.NumPredict < 0 {
+		return nil, fmt.Errorf("invalid LLM_NUM_CTX or LLM_NUM_PREDICT: must not be negative")
+	}
+	if cfg.LLM.WarmUp, err = getEnvBool("LLM_WARM_UP", true); err != nil {
+		return nil, err
+	}
+
+	cfg.LLM.RelationFamilies = getEnvList("LLM_RELATION_FAMILIES")
+	cfg.LLM.PromptsFile = os.Getenv("LLM_PROMPTS_FILE")
+	cfg.LLM.ExamplesFile = os.Getenv("LLM_EXAMPLES_FILE")
+	cfg.LLM.ExamplesDomain = getEnv("LLM_EXAMPLES_DOMAIN", "default")
+
+	if cfg.LLM.RelatedCount, err = getEnvInt("LLM_RELATED_COUNT", 5); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.RelatedCount <= 0 {
+		return nil, fmt.Errorf("invalid LLM_RELATED_COUNT: must be positive")
+	}
+	if cfg.LLM.AutoPull, err = getEnvBool("LLM_AUTO_PULL", false); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.PullTimeout, err = getEnvDuration("LLM_PULL_TIMEOUT", 30*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.CacheEnabled, err = getEnvBool("LLM_CACHE_ENABLED", true); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.CacheTTL, err = getEnvDuration("LLM_CACHE_TTL", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.LLM.CacheNegativeTTL, err = getEnvDuration("LLM_CACHE_NEGATIVE_TTL", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	// A zero TTL would expire every file cache entry, and Redis rejects expiry times below a millisecond
+	if cfg.LLM.CacheTTL < time.Millisecond || cfg.LLM.CacheNegativeTTL < time.Millisecond {
+		return nil, fmt.Errorf("invalid LLM_CACHE_TTL or LLM_CACHE_NEGATIVE_TTL: must be at least 1ms")
+	}
+	cfg.LLM.CacheBackend = getEnv("LLM_CACHE_BACKEND", "file")
+	switch cfg.LLM.CacheBackend {
+	case "file", "memory":
+	case "redis":
+		cfg.LLM.CacheRedisURL = getEnv("LLM_CACHE_REDIS_URL", "redis://localhost:6379/0")
+		if u, err := url.Parse(cfg.LLM.CacheRedisURL); err != nil || u.Scheme != "redis" || u.Host == "" {
+			return nil, fmt.Errorf("invalid LLM_CACHE_REDIS_URL: must be redis://[:password@]host:port[/db]")
+		}
+	default:
+		return nil, fmt.Errorf("invalid LLM_CACHE_BACKEND: must be file, memory or redis")
+	}
+	maxSizeMB, err := getEnvInt("LLM_CACHE_MAX_SIZE_MB", 0)
+	if err != nil {
+		return nil, err
+	}
+	if maxSizeMB < 0 {
+		return nil, fmt.Errorf("invalid LLM_CACHE_MAX_SIZE_MB: must not be negative")
+	}
+	cfg.LLM.CacheMaxSize = int64(maxSizeMB) << 20
+
+	if cfg.Graph.MaxNodes, err = getEnvInt("GRAPH_MAX_NODES", 100); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.MaxNodes <= 0 {
+		return nil, fmt.Errorf("invalid GRAPH_MAX_NODES: must be positive")
+	}
+	if cfg.Graph.MaxDepth, err = getEnvInt("GRAPH_MAX_DEPTH", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.MaxDepth < 0 {
+		return nil, fmt.Errorf("invalid GRAPH_MAX_DEPTH: must not be negative")
+	}
+	if cfg.Graph.DiversityThreshold, err = getEnvFloat("GRAPH_DIVERSITY_THRESHOLD", 0.8); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.DiversityThreshold < 0 || cfg.Graph.DiversityThreshold > 1 {
+		return nil, fmt.Errorf("invalid GRAPH_DIVERSITY_THRESHOLD: must be between 0 and 1")
+	}
+	if cfg.Graph.MinExpansionQuality, err = getEnvFloat("GRAPH_MIN_EXPANSION_QUALITY", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.MinExpansionQuality < 0 || cfg.Graph.MinExpansionQuality > 1 {
+		return nil, fmt.Errorf("invalid GRAPH_MIN_EXPANSION_QUALITY: must be between 0 and 1")
+	}
+	cfg.Graph.ConceptFilters = getEnvList("GRAPH_CONCEPT_FILTERS")
+	for _, filter := range cfg.Graph.ConceptFilters {
+		if !conceptFilters[filter] {
+			return nil, fmt.Errorf("invalid GRAPH_CONCEPT_FILTERS: unknown filter %q", filter)
+		}
+	}
+	cfg.Graph.StopConceptsFile = os.Getenv("GRAPH_STOP_CONCEPTS_FILE")
+	cfg.Graph.ScreenDetectors = getEnvList("GRAPH_SCREEN_DETECTORS")
+	for _, detector := range cfg.Graph.ScreenDetectors {
+		if !screenDetectors[detector] {
+			return nil, fmt.Errorf("invalid GRAPH_SCREEN_DETECTORS: unknown detector %q", detector)
+		}
+	}
+	cfg.Graph.ScreenWordlist = os.Getenv("GRAPH_SCREEN_WORDLIST")
+	cfg.Graph.ReviewFile = getEnv("GRAPH_REVIEW_FILE", "review/flagged.jsonl")
+
+	cfg.Graph.StopConditions = getEnvList("GRAPH_STOP_CONDITIONS")
+	if os.Getenv("GRAPH_STOP_CONDITIONS") == "" {
+		cfg.Graph.StopConditions = []string{"frontier-empty"}
+	}
+	for _, condition := range cfg.Graph.StopConditions {
+		if !stopConditions[condition] {
+			return nil, fmt.Errorf("invalid GRAPH_STOP_CONDITIONS: unknown condition %q", condition)
+		}
+	}
+	if cfg.Graph.MinNovelty, err = getEnvFloat("GRAPH_MIN_NOVELTY", 0.2); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.MinNovelty < 0 || cfg.Graph.MinNovelty > 1 {
+		return nil, fmt.Errorf("invalid GRAPH_MIN_NOVELTY: must be between 0 and 1")
+	}
+	if cfg.Graph.NoveltyWindow, err = getEnvInt("GRAPH_NOVELTY_WINDOW", 10); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.NoveltyWindow <= 0 {
+		return nil, fmt.Errorf("invalid GRAPH_NOVELTY_WINDOW: must be positive")
+	}
+	if cfg.Graph.LLMBudget, err = getEnvInt("GRAPH_LLM_BUDGET", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.StallTimeout, err = getEnvDuration("GRAPH_STALL_TIMEOUT", 0); err != nil {
+		return nil, err
+	}
+	cfg.Graph.StallAction = getEnv("GRAPH_STALL_ACTION", "stop")
+	if cfg.Graph.StallAction != "stop" && cfg.Graph.StallAction != "restart" && cfg.Graph.StallAction != "alert" {
+		return nil, fmt.Errorf("invalid GRAPH_STALL_ACTION: must be stop, restart or alert")
+	}
+	if cfg.Graph.StallRestarts, err = getEnvInt("GRAPH_STALL_RESTARTS", 3); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.StallRestarts < 0 {
+		return nil, fmt.Errorf("invalid GRAPH_STALL_RESTARTS: must not be negative")
+	}
+	cfg.Graph.StallWebhook = os.Getenv("GRAPH_STALL_WEBHOOK")
+	if cfg.Graph.DriftWindow, err = getEnvInt("GRAPH_DRIFT_WINDOW", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.DriftThreshold, err = getEnvFloat("GRAPH_DRIFT_THRESHOLD", 0.2); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.DriftThreshold <= 0 || cfg.Graph.DriftThreshold >= 1 {
+		return nil, fmt.Errorf("invalid GRAPH_DRIFT_THRESHOLD: must be between 0 and 1")
+	}
+	cfg.Graph.DriftWebhook = os.Getenv("GRAPH_DRIFT_WEBHOOK")
+	if cfg.Graph.OutageBufferSize, err = getEnvInt("GRAPH_OUTAGE_BUFFER", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.OutageBufferSize <= 0 {
+		return nil, fmt.Errorf("invalid GRAPH_OUTAGE_BUFFER: must be positive")
+	}
+	if cfg.Graph.OutageRetryInterval, err = getEnvDuration("GRAPH_OUTAGE_RETRY_INTERVAL", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.OutageRetryInterval <= 0 {
+		return nil, fmt.Errorf("invalid GRAPH_OUTAGE_RETRY_INTERVAL: must be positive")
+	}
+	if cfg.Graph.Neo4jBreakerThreshold, err = getEnvInt("GRAPH_NEO4J_BREAKER_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.Neo4jBreakerThreshold < 0 {
+		return nil, fmt.Errorf("invalid GRAPH_NEO4J_BREAKER_THRESHOLD: must not be negative")
+	}
+	if cfg.Graph.Neo4jBreakerCooldown, err = getEnvDuration("GRAPH_NEO4J_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.Neo4jBreakerCooldown <= 0 {
+		return nil, fmt.Errorf("invalid GRAPH_NEO4J_BREAKER_COOLDOWN: must be positive")
+	}
+	cfg.Graph.RelationAllowlist = getEnvList("GRAPH_RELATION_ALLOWLIST")
+	cfg.Graph.RelationStrategy = getEnv("GRAPH_RELATION_STRATEGY", "property")
+	if cfg.Graph.RelationStrategy != "property" && cfg.Graph.RelationStrategy != "type" {
+		return nil, fmt.Errorf("invalid GRAPH_RELATION_STRATEGY: must be property or type")
+	}
+	cfg.Graph.WALPath = getEnv("GRAPH_WAL_PATH", "wal/relationships.wal")
+	if cfg.Graph.WALPath == "none" {
+		cfg.Graph.WALPath = ""
+	}
+	cfg.Graph.MineTag = os.Getenv("GRAPH_MINE_TAG")
+	if cfg.Graph.ReadOnly, err = getEnvBool("GRAPH_READ_ONLY", false); err != nil {
+		return nil, err
+	}
+	if cfg.Graph.SlowQueryThreshold, err = getEnvDuration("NEO4J_SLOW_QUERY_THRESHOLD", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	for _, condition := range cfg.Graph.StopConditions {
+		if condition == "budget" && cfg.Graph.LLMBudget <= 0 {
+			return nil, fmt.Errorf("the budget stop condition requires a positive GRAPH_LLM_BUDGET")
+		}
+	}
+
+	if cfg.Log, err = LoadLog(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadLog reads the logging settings from LOG_LEVEL and LOG_FORMAT.
+func LoadLog() (LogConfig, error) {
+	cfg := LogConfig{Level: getEnv("LOG_LEVEL", "info"), Format: getEnv("LOG_FORMAT", logging.FormatText)}
+	if _, _, err := logging.ParseLevels(cfg.Level); err != nil {
+		return LogConfig{}, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	if cfg.Format != logging.FormatText && cfg.Format != logging.FormatJSON {
+		return LogConfig{}, fmt.Errorf("invalid LOG_FORMAT: must be text or json")
+	}
+	return cfg, nil
+}
+
+// getEnv returns the value of the environment variable or the default if it is unset.
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvList returns the comma-separated values of the environment variable, ignoring empty items.
+func getEnvList(key string) []string {
+	var values []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func getEnvBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvFloat(key string, def float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func getEnvDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,208 @@
+// Package eval scores the relationships the builder extracts against a gold standard.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"kg-builder/internal/models"
+	"kg-builder/internal/neo4j"
+)
+
+// Score counts matches between predicted and gold items.
+type Score struct {
+	Matched   int `json:"matched"`
+	Predicted int `json:"predicted"`
+	Gold      int `json:"gold"`
+}
+
+// Precision returns the share of predicted items that are in the gold standard.
+func (s Score) Precision() float64 {
+	if s.Predicted == 0 {
+		return 0
+	}
+	return float64(s.Matched) / float64(s.Predicted)
+}
+
+// Recall returns the share of gold items that were predicted.
+func (s Score) Recall() float64 {
+	if s.Gold == 0 {
+		return 0
+	}
+	return float64(s.Matched) / float64(s.Gold)
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (s Score) F1() float64 {
+	p, r := s.Precision(), s.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+func (s Score) add(other Score) Score {
+	return Score{Matched: s.Matched + other.Matched, Predicted: s.Predicted + other.Predicted, Gold: s.Gold + other.Gold}
+}
+
+// SeedReport scores the expansion of one seed concept.
+type SeedReport struct {
+	Seed       string   `json:"seed"`
+	Concepts   Score    `json:"concepts"`
+	Relations  Score    `json:"relations"`
+	Missing    []string `json:"missing,omitempty"`    // Gold relationships that were not predicted
+	Unexpected []string `json:"unexpected,omitempty"` // Predicted relationships that are not in the gold standard
+}
+
+// Report scores the expansions of all seeds. Concepts counts the related concepts found regardless of the relation,
+// Relations the relationships whose relation type matches too.
+type Report struct {
+	Concepts  Score        `json:"concepts"`
+	Relations Score        `json:"relations"`
+	Mining    *Score       `json:"mining,omitempty"` // Relation types found by mining the gold pairs, if they were mined
+	Seeds     []SeedReport `json:"seeds"`
+}
+
+// LoadGold reads a gold standard: a JSON array of relationships in the format the LLM returns them, like the bundled
+// sample graph.
+func LoadGold(path string) ([]models.Concept, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var relationships []models.Concept
+	if err := json.Unmarshal(data, &relationships); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return relationships, nil
+}
+
+// Seeds returns the concepts the gold relationships start from, which are expanded to evaluate the builder.
+func Seeds(gold []models.Concept) []string {
+	seen := map[string]bool{}
+	var seeds []string
+	for _, r := range gold {
+		if !seen[conceptKey(r.RelatedTo)] {
+			seen[conceptKey(r.RelatedTo)] = true
+			seeds = append(seeds, r.RelatedTo)
+		}
+	}
+	return seeds
+}
+
+// FromSeeds returns the relationships starting from one of the seeds.
+func FromSeeds(relationships []models.Concept, seeds []string) []models.Concept {
+	keep := map[string]bool{}
+	for _, seed := range seeds {
+		keep[conceptKey(seed)] = true
+	}
+	var result []models.Concept
+	for _, r := range relationships {
+		if keep[conceptKey(r.RelatedTo)] {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Evaluate scores the predicted expansions of the seeds against the gold relationships starting from them. Concept
+// names are compared ignoring case and spacing, relation types after sanitizing them as they are stored.
+func Evaluate(gold, predicted []models.Concept, seeds []string) Report {
+	var report Report
+	for _, seed := range seeds {
+		goldTriples := triples(gold, seed)
+		predictedTriples := triples(predicted, seed)
+		s := SeedReport{
+			Seed:     seed,
+			Concepts: compare(concepts(goldTriples), concepts(predictedTriples), nil, nil),
+		}
+		s.Relations = compare(goldTriples, predictedTriples, &s.Missing, &s.Unexpected)
+		report.Concepts = report.Concepts.add(s.Concepts)
+		report.Relations = report.Relations.add(s.Relations)
+		report.Seeds = append(report.Seeds, s)
+	}
+	return report
+}
+
+// EvaluateMining scores the relationships mined for the gold pairs: mined holds what mining returned for the pairs it
+// found related, and a pair matches if its relation type is the gold one.
+func EvaluateMining(gold, mined []models.Concept) Score {
+	goldTypes := map[string]string{}
+	for _, r := range gold {
+		goldTypes[pairKey(r)] = neo4j.SanitizeRelationType(r.Relation)
+	}
+	score := Score{Gold: len(goldTypes)}
+	seen := map[string]bool{}
+	for _, r := range mined {
+		if seen[pairKey(r)] {
+			continue
+		}
+		seen[pairKey(r)] = true
+		score.Predicted++
+		if t, ok := goldTypes[pairKey(r)]; ok && t == neo4j.SanitizeRelationType(r.Relation) {
+			score.Matched++
+		}
+	}
+	return score
+}
+
+// triples returns the relationships starting from the seed, keyed by their normalized form and valued by their
+// readable one.
+func triples(relationships []models.Concept, seed string) map[string]string {
+	result := map[string]string{}
+	for _, r := range relationships {
+		if conceptKey(r.RelatedTo) != conceptKey(seed) {
+			continue
+		}
+		relationType := neo4j.SanitizeRelationType(r.Relation)
+		result[conceptKey(r.Name)+"\x00"+relationType] = fmt.Sprintf("%s -[%s]-> %s", r.RelatedTo, relationType, r.Name)
+	}
+	return result
+}
+
+// concepts reduces triples to the related concepts they reach.
+func concepts(triples map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, triple := range triples {
+		name, _, _ := strings.Cut(key, "\x00")
+		result[name] = triple
+	}
+	return result
+}
+
+// compare scores predicted against gold, listing the differences in missing and unexpected if they are given.
+func compare(gold, predicted map[string]string, missing, unexpected *[]string) Score {
+	score := Score{Predicted: len(predicted), Gold: len(gold)}
+	for key, triple := range gold {
+		if _, ok := predicted[key]; ok {
+			score.Matched++
+		} else if missing != nil {
+			*missing = append(*missing, triple)
+		}
+	}
+	if unexpected != nil {
+		for key, triple := range predicted {
+			if _, ok := gold[key]; !ok {
+				*unexpected = append(*unexpected, triple)
+			}
+		}
+		sort.Strings(*unexpected)
+	}
+	if missing != nil {
+		sort.Strings(*missing)
+	}
+	return score
+}
+
+// conceptKey normalizes a concept name for comparison.
+func conceptKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// pairKey identifies the pair of concepts of a relationship.
+func pairKey(r models.Concept) string {
+	return conceptKey(r.RelatedTo) + "\x00" + conceptKey(r.Name)
+}
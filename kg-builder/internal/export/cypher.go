@@ -0,0 +1,119 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kg-builder/internal/neo4j"
+)
+
+// cypherBatchSize is the number of statements per transaction of a Cypher dump.
+const cypherBatchSize = 1000
+
+// Cypher writes the graph as an idempotent Cypher script for cypher-shell: the recommended constraints and indexes,
+// then MERGE statements for the concepts and relationships in transactions of cypherBatchSize statements. The
+// relationships are written with the configured relation strategy.
+func Cypher(w io.Writer, graph *neo4j.Graph) error {
+	strategy := neo4j.RelationStrategy()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// Knowledge graph: %d concepts, %d relationships, %s relation strategy\n", len(graph.Concepts), len(graph.Relationships), strategy)
+	for _, statement := range neo4j.SchemaStatements() {
+		fmt.Fprintf(bw, "%s;\n", statement)
+	}
+
+	statements := 0
+	write := func(format string, args ...interface{}) {
+		if statements%cypherBatchSize == 0 {
+			if statements > 0 {
+				fmt.Fprintln(bw, ":commit")
+			}
+			fmt.Fprintln(bw, ":begin")
+		}
+		fmt.Fprintf(bw, format+";\n", args...)
+		statements++
+	}
+
+	for _, c := range graph.Concepts {
+		if len(c.Properties) == 0 {
+			write("MERGE (:Concept {name: %s})", cypherValue(c.Name))
+			continue
+		}
+		write("MERGE (c:Concept {name: %s}) SET c += %s", cypherValue(c.Name), cypherMap(c.Properties))
+	}
+	for _, r := range graph.Relationships {
+		pattern := "[r:RELATED_TO {type: " + cypherValue(r.Type) + "}]"
+		if strategy == neo4j.RelationStrategyType {
+			pattern = "[r:`" + strings.ReplaceAll(r.Type, "`", "``") + "`]" // Quoted like the builder's own writes
+		}
+		set := ""
+		if r.Description != "" {
+			set = " SET r.description = " + cypherValue(r.Description)
+		}
+		write("MATCH (a:Concept {name: %s}), (b:Concept {name: %s}) MERGE (a)-%s->(b)%s", cypherValue(r.From), cypherValue(r.To), pattern, set)
+	}
+	if statements > 0 {
+		fmt.Fprintln(bw, ":commit")
+	}
+	return bw.Flush()
+}
+
+// cypherMap returns the properties as a Cypher map literal, with the keys sorted.
+func cypherMap(properties map[string]interface{}) string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, cypherIdentifier(key)+": "+cypherValue(properties[key]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// cypherValue returns a property value as a Cypher literal. Values of other types are written as strings.
+func cypherValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(v) + "'"
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "datetime(" + cypherValue(v.Format(time.RFC3339Nano)) + ")"
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, cypherValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return cypherValue(fmt.Sprint(v))
+	}
+}
+
+// cypherIdentifier returns name as a Cypher identifier, quoted unless it is a plain one.
+func cypherIdentifier(name string) string {
+	plain := name != ""
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"kg-builder/internal/neo4j"
+)
+
+// Mermaid writes the graph as a Mermaid flowchart, highlighting the focus concept.
+func Mermaid(w io.Writer, graph *neo4j.Graph, focus string) error {
+	bw := bufio.NewWriter(w)
+	ids := make(map[string]string, len(graph.Concepts))
+	fmt.Fprintln(bw, "flowchart LR")
+	for i, c := range graph.Concepts {
+		ids[c.Name] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(bw, "    %s[\"%s\"]\n", ids[c.Name], mermaidEscape(c.Name))
+	}
+	for _, r := range graph.Relationships {
+		from, to := ids[r.From], ids[r.To]
+		if from == "" || to == "" {
+			continue
+		}
+		fmt.Fprintf(bw, "    %s -->|\"%s\"| %s\n", from, mermaidEscape(r.Type), to)
+	}
+	if id, ok := ids[focus]; ok {
+		fmt.Fprintln(bw, "    classDef focus stroke-width:3px,font-weight:bold")
+		fmt.Fprintf(bw, "    class %s focus\n", id)
+	}
+	return bw.Flush()
+}
+
+// mermaidEscape escapes text for a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// DOT writes the graph in the Graphviz DOT language, highlighting the focus concept.
+func DOT(w io.Writer, graph *neo4j.Graph, focus string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(focus))
+	fmt.Fprintln(bw, "    rankdir=LR;")
+	fmt.Fprintln(bw, "    node [shape=box];")
+	for _, c := range graph.Concepts {
+		if c.Name == focus {
+			fmt.Fprintf(bw, "    %s [style=\"bold,filled\", fillcolor=lightyellow];\n", dotQuote(c.Name))
+			continue
+		}
+		fmt.Fprintf(bw, "    %s;\n", dotQuote(c.Name))
+	}
+	for _, r := range graph.Relationships {
+		fmt.Fprintf(bw, "    %s -> %s [label=%s];\n", dotQuote(r.From), dotQuote(r.To), dotQuote(r.Type))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotQuote returns s as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
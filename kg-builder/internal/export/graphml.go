@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"kg-builder/internal/neo4j"
+)
+
+// GraphWriter writes a graph element by element, so a graph can be exported as it is read. All concepts must be
+// written before the first relationship.
+type GraphWriter interface {
+	Concept(c neo4j.ConceptNode) error
+	Relationship(r neo4j.Relationship) error
+	Close() error // Writes the end of the document and flushes it
+}
+
+// graphML writes GraphML, read by yEd, Gephi and most graph libraries.
+type graphML struct {
+	w     *bufio.Writer
+	edges int
+}
+
+// NewGraphML returns a GraphWriter writing GraphML to w.
+func NewGraphML(w io.Writer) GraphWriter {
+	g := &graphML{w: bufio.NewWriter(w)}
+	g.w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="name" for="node" attr.name="name" attr.type="string"/>
+  <key id="tags" for="node" attr.name="tags" attr.type="string"/>
+  <key id="aliases" for="node" attr.name="aliases" attr.type="string"/>
+  <key id="type" for="edge" attr.name="type" attr.type="string"/>
+  <key id="description" for="edge" attr.name="description" attr.type="string"/>
+  <graph id="kg" edgedefault="directed">
+`)
+	return g
+}
+
+func (g *graphML) Concept(c neo4j.ConceptNode) error {
+	fmt.Fprintf(g.w, "    <node id=\"%s\">\n", xmlEscape(c.Name))
+	g.data("name", c.Name)
+	g.data("tags", listProperty(c, "tags"))
+	g.data("aliases", listProperty(c, "aliases"))
+	_, err := g.w.WriteString("    </node>\n")
+	return err
+}
+
+func (g *graphML) Relationship(r neo4j.Relationship) error {
+	fmt.Fprintf(g.w, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", g.edges, xmlEscape(r.From), xmlEscape(r.To))
+	g.edges++
+	g.data("type", r.Type)
+	g.data("description", r.Description)
+	_, err := g.w.WriteString("    </edge>\n")
+	return err
+}
+
+func (g *graphML) Close() error {
+	g.w.WriteString("  </graph>\n</graphml>\n")
+	return g.w.Flush()
+}
+
+// data writes a data element unless the value is empty.
+func (g *graphML) data(key, value string) {
+	if value != "" {
+		fmt.Fprintf(g.w, "      <data key=\"%s\">%s</data>\n", key, xmlEscape(value))
+	}
+}
+
+// gexf writes GEXF, Gephi's native format.
+type gexf struct {
+	w     *bufio.Writer
+	edges int // Relationships written; the first one closes the nodes section
+}
+
+// NewGEXF returns a GraphWriter writing GEXF 1.2 to w.
+func NewGEXF(w io.Writer) GraphWriter {
+	g := &gexf{w: bufio.NewWriter(w)}
+	g.w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://www.gexf.net/1.2draft" version="1.2">
+  <meta><creator>kg-builder</creator></meta>
+  <graph defaultedgetype="directed">
+    <attributes class="node">
+      <attribute id="tags" title="tags" type="string"/>
+      <attribute id="aliases" title="aliases" type="string"/>
+    </attributes>
+    <attributes class="edge">
+      <attribute id="description" title="description" type="string"/>
+    </attributes>
+    <nodes>
+`)
+	return g
+}
+
+func (g *gexf) Concept(c neo4j.ConceptNode) error {
+	if g.edges > 0 {
+		return fmt.Errorf("concept %s written after the relationships", c.Name)
+	}
+	fmt.Fprintf(g.w, "      <node id=\"%s\" label=\"%s\">\n", xmlEscape(c.Name), xmlEscape(c.Name))
+	g.attValues(map[string]string{"tags": listProperty(c, "tags"), "aliases": listProperty(c, "aliases")}, "tags", "aliases")
+	_, err := g.w.WriteString("      </node>\n")
+	return err
+}
+
+func (g *gexf) Relationship(r neo4j.Relationship) error {
+	if g.edges == 0 {
+		g.w.WriteString("    </nodes>\n    <edges>\n")
+	}
+	fmt.Fprintf(g.w, "      <edge id=\"%d\" source=\"%s\" target=\"%s\" label=\"%s\">\n", g.edges, xmlEscape(r.From), xmlEscape(r.To), xmlEscape(r.Type))
+	g.edges++
+	g.attValues(map[string]string{"description": r.Description}, "description")
+	_, err := g.w.WriteString("      </edge>\n")
+	return err
+}
+
+func (g *gexf) Close() error {
+	if g.edges == 0 {
+		g.w.WriteString("    </nodes>\n    <edges>\n")
+	}
+	g.w.WriteString("    </edges>\n  </graph>\n</gexf>\n")
+	return g.w.Flush()
+}
+
+// attValues writes the non-empty values of the attributes, in the given order.
+func (g *gexf) attValues(values map[string]string, order ...string) {
+	started := false
+	for _, id := range order {
+		if values[id] == "" {
+			continue
+		}
+		if !started {
+			g.w.WriteString("        <attvalues>\n")
+			started = true
+		}
+		fmt.Fprintf(g.w, "          <attvalue for=\"%s\" value=\"%s\"/>\n", id, xmlEscape(values[id]))
+	}
+	if started {
+		g.w.WriteString("        </attvalues>\n")
+	}
+}
+
+// listProperty returns a list property of the concept joined with semicolons.
+func listProperty(c neo4j.ConceptNode, key string) string {
+	return strings.Join(listValues(c, key), "; ")
+}
+
+// xmlEscape escapes text for XML character data and attribute values.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
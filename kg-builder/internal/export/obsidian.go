@@ -0,0 +1,144 @@
+// Package export writes the knowledge graph in formats other tools can read.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kg-builder/internal/neo4j"
+)
+
+// obsidianForbidden are the characters Obsidian does not allow in note names.
+const obsidianForbidden = `*"\/<>:|?#^[]`
+
+// Obsidian writes one Markdown note per concept into dir, forming an Obsidian vault. Relationships become wiki-links
+// and the concept's properties, such as tags, aliases and merge or split provenance, its front matter. Existing notes
+// of the same name are overwritten.
+func Obsidian(graph *neo4j.Graph, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create vault directory: %w", err)
+	}
+
+	notes := noteNames(graph.Concepts)
+	outgoing := make(map[string][]neo4j.Relationship)
+	incoming := make(map[string][]neo4j.Relationship)
+	for _, r := range graph.Relationships {
+		outgoing[r.From] = append(outgoing[r.From], r)
+		incoming[r.To] = append(incoming[r.To], r)
+	}
+
+	link := func(concept string) string {
+		note, ok := notes[concept]
+		if !ok {
+			note = noteName(concept) // Relationship to a concept missing from the snapshot
+		}
+		if note == concept {
+			return "[[" + concept + "]]"
+		}
+		return "[[" + note + "|" + concept + "]]"
+	}
+
+	for _, c := range graph.Concepts {
+		var b strings.Builder
+		b.WriteString("---\n")
+		writeFrontMatter(&b, c)
+		b.WriteString("---\n\n")
+		fmt.Fprintf(&b, "# %s\n", c.Name)
+		if description, ok := c.Properties["description"].(string); ok && description != "" {
+			fmt.Fprintf(&b, "\n%s\n", description)
+		}
+
+		if rs := outgoing[c.Name]; len(rs) > 0 {
+			b.WriteString("\n## Relationships\n\n")
+			for _, r := range rs {
+				fmt.Fprintf(&b, "- %s %s%s\n", r.Type, link(r.To), describe(r))
+			}
+		}
+		if rs := incoming[c.Name]; len(rs) > 0 {
+			b.WriteString("\n## Referenced by\n\n")
+			for _, r := range rs {
+				fmt.Fprintf(&b, "- %s %s%s\n", link(r.From), r.Type, describe(r))
+			}
+		}
+
+		path := filepath.Join(dir, notes[c.Name]+".md")
+		if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write note for %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// writeFrontMatter writes the concept's properties as YAML front matter. Values are written as JSON, which is valid
+// YAML, and tags have their spaces replaced since Obsidian tags cannot contain any.
+func writeFrontMatter(b *strings.Builder, c neo4j.ConceptNode) {
+	title, _ := json.Marshal(c.Name)
+	fmt.Fprintf(b, "title: %s\n", title)
+
+	keys := make([]string, 0, len(c.Properties))
+	for key := range c.Properties {
+		if key != "description" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := c.Properties[key]
+		if key == "tags" {
+			if tags, ok := value.([]interface{}); ok {
+				cleaned := make([]string, 0, len(tags))
+				for _, t := range tags {
+					cleaned = append(cleaned, strings.Join(strings.Fields(fmt.Sprint(t)), "-"))
+				}
+				value = cleaned
+			}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprint(value))
+		}
+		fmt.Fprintf(b, "%s: %s\n", key, data)
+	}
+}
+
+// describe returns the relationship's description as a suffix of its list item.
+func describe(r neo4j.Relationship) string {
+	if r.Description == "" {
+		return ""
+	}
+	return " (" + r.Description + ")"
+}
+
+// noteNames returns a unique note name for each concept.
+func noteNames(concepts []neo4j.ConceptNode) map[string]string {
+	names := make(map[string]string, len(concepts))
+	used := make(map[string]bool, len(concepts))
+	for _, c := range concepts {
+		name := noteName(c.Name)
+		for i := 2; used[strings.ToLower(name)]; i++ { // Note names are case-insensitive on some file systems
+			name = fmt.Sprintf("%s (%d)", noteName(c.Name), i)
+		}
+		used[strings.ToLower(name)] = true
+		names[c.Name] = name
+	}
+	return names
+}
+
+// noteName replaces the characters Obsidian does not allow in note names.
+func noteName(concept string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(obsidianForbidden, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(concept))
+	name = strings.TrimLeft(name, ".") // Hidden files are not shown
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
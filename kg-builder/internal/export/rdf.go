@@ -0,0 +1,229 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"kg-builder/internal/neo4j"
+)
+
+// RDFMapping controls how concepts and relationships are mapped to RDF triples.
+type RDFMapping struct {
+	ConceptIRI   string            `json:"conceptIri"`   // IRI template of concepts; {name} is replaced by the escaped name
+	PredicateIRI string            `json:"predicateIri"` // IRI template of relation types without a predicate; {type} is replaced by the type
+	ConceptClass string            `json:"conceptClass"` // Class of every concept
+	Predicates   map[string]string `json:"predicates"`   // Predicate of each relation type, e.g. "IsA": "rdfs:subClassOf"
+	Prefixes     map[string]string `json:"prefixes"`     // Prefixes usable in the other fields and written to the output
+}
+
+// DefaultRDFMapping returns the mapping used without a mapping file: concepts are SKOS concepts and relation types
+// predicates under example.org.
+func DefaultRDFMapping() *RDFMapping {
+	return &RDFMapping{
+		ConceptIRI:   "https://example.org/concept/{name}",
+		PredicateIRI: "https://example.org/relation/{type}",
+		ConceptClass: "skos:Concept",
+		Predicates:   map[string]string{},
+		Prefixes: map[string]string{
+			"rdf":  "http://www.w3.org/1999/02/22-rdf-syntax-ns#",
+			"rdfs": "http://www.w3.org/2000/01/rdf-schema#",
+			"skos": "http://www.w3.org/2004/02/skos/core#",
+		},
+	}
+}
+
+// LoadRDFMapping reads a JSON mapping file. Fields it leaves out keep their defaults; its prefixes are added to the
+// default ones.
+func LoadRDFMapping(path string) (*RDFMapping, error) {
+	mapping := DefaultRDFMapping()
+	if path == "" {
+		return mapping, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDF mapping: %w", err)
+	}
+	var file RDFMapping
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse RDF mapping %s: %w", path, err)
+	}
+	if file.ConceptIRI != "" {
+		mapping.ConceptIRI = file.ConceptIRI
+	}
+	if file.PredicateIRI != "" {
+		mapping.PredicateIRI = file.PredicateIRI
+	}
+	if file.ConceptClass != "" {
+		mapping.ConceptClass = file.ConceptClass
+	}
+	for t, p := range file.Predicates {
+		mapping.Predicates[t] = p
+	}
+	for prefix, iri := range file.Prefixes {
+		mapping.Prefixes[prefix] = iri
+	}
+	if !strings.Contains(mapping.ConceptIRI, "{name}") {
+		return nil, fmt.Errorf("conceptIri %q must contain {name}", mapping.ConceptIRI)
+	}
+	return mapping, nil
+}
+
+// conceptIRI returns the IRI of a concept.
+func (m *RDFMapping) conceptIRI(name string) string {
+	return m.expand(strings.ReplaceAll(m.ConceptIRI, "{name}", url.PathEscape(name)))
+}
+
+// predicateIRI returns the IRI of the predicate a relation type is mapped to.
+func (m *RDFMapping) predicateIRI(relationType string) string {
+	if p, ok := m.Predicates[relationType]; ok {
+		return m.expand(p)
+	}
+	return m.expand(strings.ReplaceAll(m.PredicateIRI, "{type}", url.PathEscape(relationType)))
+}
+
+// expand turns a prefixed name such as rdfs:label into a full IRI. Other values are returned unchanged.
+func (m *RDFMapping) expand(iri string) string {
+	if prefix, local, ok := strings.Cut(iri, ":"); ok {
+		if base, ok := m.Prefixes[prefix]; ok && !strings.HasPrefix(local, "//") {
+			return base + local
+		}
+	}
+	return iri
+}
+
+// sortedPrefixes returns the prefixes in a stable order.
+func (m *RDFMapping) sortedPrefixes() []string {
+	prefixes := make([]string, 0, len(m.Prefixes))
+	for prefix := range m.Prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// turtle writes RDF in Turtle.
+type turtle struct {
+	w       *bufio.Writer
+	mapping *RDFMapping
+}
+
+// NewTurtle returns a GraphWriter writing the graph as RDF in Turtle. Concepts are typed with the concept class,
+// labeled with rdfs:label and have their aliases as skos:altLabel; relationships become triples of their predicate.
+func NewTurtle(w io.Writer, mapping *RDFMapping) GraphWriter {
+	t := &turtle{w: bufio.NewWriter(w), mapping: mapping}
+	for _, prefix := range mapping.sortedPrefixes() {
+		fmt.Fprintf(t.w, "@prefix %s: <%s> .\n", prefix, turtleIRIEscape(mapping.Prefixes[prefix]))
+	}
+	t.w.WriteString("\n")
+	return t
+}
+
+func (t *turtle) Concept(c neo4j.ConceptNode) error {
+	fmt.Fprintf(t.w, "<%s> a <%s> ;\n    <%s> %s",
+		turtleIRIEscape(t.mapping.conceptIRI(c.Name)), turtleIRIEscape(t.mapping.expand(t.mapping.ConceptClass)),
+		turtleIRIEscape(t.mapping.expand("rdfs:label")), turtleLiteral(c.Name))
+	for _, alias := range listValues(c, "aliases") {
+		fmt.Fprintf(t.w, " ;\n    <%s> %s", turtleIRIEscape(t.mapping.expand("skos:altLabel")), turtleLiteral(alias))
+	}
+	_, err := t.w.WriteString(" .\n")
+	return err
+}
+
+func (t *turtle) Relationship(r neo4j.Relationship) error {
+	_, err := fmt.Fprintf(t.w, "<%s> <%s> <%s> .\n", turtleIRIEscape(t.mapping.conceptIRI(r.From)),
+		turtleIRIEscape(t.mapping.predicateIRI(r.Type)), turtleIRIEscape(t.mapping.conceptIRI(r.To)))
+	return err
+}
+
+func (t *turtle) Close() error {
+	return t.w.Flush()
+}
+
+// turtleLiteral returns s as a Turtle string literal.
+func turtleLiteral(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(s) + `"`
+}
+
+// turtleIRIEscape escapes the characters not allowed in a Turtle IRI reference.
+func turtleIRIEscape(iri string) string {
+	var b strings.Builder
+	for _, r := range iri {
+		if r <= ' ' || strings.ContainsRune(`<>"{}|^`+"`"+`\`, r) {
+			fmt.Fprintf(&b, "%%%02X", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// jsonLD writes RDF as a JSON-LD document with one node object per concept and per relationship, which JSON-LD
+// processors merge by @id.
+type jsonLD struct {
+	w       *bufio.Writer
+	mapping *RDFMapping
+	nodes   int
+}
+
+// NewJSONLD returns a GraphWriter writing the graph as RDF in JSON-LD, with the same triples as NewTurtle.
+func NewJSONLD(w io.Writer, mapping *RDFMapping) GraphWriter {
+	j := &jsonLD{w: bufio.NewWriter(w), mapping: mapping}
+	context, _ := json.Marshal(mapping.Prefixes)
+	fmt.Fprintf(j.w, "{\n  \"@context\": %s,\n  \"@graph\": [", context)
+	return j
+}
+
+func (j *jsonLD) Concept(c neo4j.ConceptNode) error {
+	node := map[string]interface{}{
+		"@id":                          j.mapping.conceptIRI(c.Name),
+		"@type":                        j.mapping.expand(j.mapping.ConceptClass),
+		j.mapping.expand("rdfs:label"): c.Name,
+	}
+	if aliases := listValues(c, "aliases"); len(aliases) > 0 {
+		node[j.mapping.expand("skos:altLabel")] = aliases
+	}
+	return j.write(node)
+}
+
+func (j *jsonLD) Relationship(r neo4j.Relationship) error {
+	return j.write(map[string]interface{}{
+		"@id":                          j.mapping.conceptIRI(r.From),
+		j.mapping.predicateIRI(r.Type): map[string]string{"@id": j.mapping.conceptIRI(r.To)},
+	})
+}
+
+func (j *jsonLD) Close() error {
+	j.w.WriteString("\n  ]\n}\n")
+	return j.w.Flush()
+}
+
+// write adds a node object to the graph.
+func (j *jsonLD) write(node map[string]interface{}) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	if j.nodes > 0 {
+		j.w.WriteString(",")
+	}
+	j.nodes++
+	j.w.WriteString("\n    ")
+	_, err = j.w.Write(data)
+	return err
+}
+
+// listValues returns the values of a list property of the concept as strings.
+func listValues(c neo4j.ConceptNode, key string) []string {
+	values, _ := c.Properties[key].([]interface{})
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		items = append(items, fmt.Sprint(v))
+	}
+	return items
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"strings"
+
+	"kg-builder/internal/neo4j"
+)
+
+// Scrub strips a graph down to its topology for sharing: concept names and relation types are kept, while concept
+// properties (descriptions, tags, aliases, provenance) and relationship descriptions are dropped. Concepts carrying
+// one of the excluded tags are dropped with their relationships.
+type Scrub struct {
+	excludeTags map[string]bool
+	dropped     map[string]bool // Names of the concepts dropped so far
+}
+
+// NewScrub returns a scrub dropping the concepts tagged with any of excludeTags, compared ignoring case.
+func NewScrub(excludeTags []string) *Scrub {
+	s := &Scrub{excludeTags: make(map[string]bool), dropped: make(map[string]bool)}
+	for _, tag := range excludeTags {
+		s.excludeTags[strings.ToLower(tag)] = true
+	}
+	return s
+}
+
+// Concept returns the scrubbed concept, or false if it is dropped.
+func (s *Scrub) Concept(c neo4j.ConceptNode) (neo4j.ConceptNode, bool) {
+	for _, tag := range listValues(c, "tags") {
+		if s.excludeTags[strings.ToLower(tag)] {
+			s.dropped[c.Name] = true
+			return neo4j.ConceptNode{}, false
+		}
+	}
+	return neo4j.ConceptNode{Name: c.Name, Properties: map[string]interface{}{}}, true
+}
+
+// Relationship returns the scrubbed relationship, or false if it touches a dropped concept. Concepts must be passed
+// to Concept first.
+func (s *Scrub) Relationship(r neo4j.Relationship) (neo4j.Relationship, bool) {
+	if s.dropped[r.From] || s.dropped[r.To] {
+		return neo4j.Relationship{}, false
+	}
+	return neo4j.Relationship{From: r.From, To: r.To, Type: r.Type}, true
+}
+
+// Graph returns a scrubbed copy of the graph.
+func (s *Scrub) Graph(g *neo4j.Graph) *neo4j.Graph {
+	scrubbed := &neo4j.Graph{}
+	for _, c := range g.Concepts {
+		if c, ok := s.Concept(c); ok {
+			scrubbed.Concepts = append(scrubbed.Concepts, c)
+		}
+	}
+	for _, r := range g.Relationships {
+		if r, ok := s.Relationship(r); ok {
+			scrubbed.Relationships = append(scrubbed.Relationships, r)
+		}
+	}
+	return scrubbed
+}
+
+// Writer returns a GraphWriter passing the scrubbed graph on to w.
+func (s *Scrub) Writer(w GraphWriter) GraphWriter {
+	return &scrubWriter{scrub: s, w: w}
+}
+
+type scrubWriter struct {
+	scrub *Scrub
+	w     GraphWriter
+}
+
+func (sw *scrubWriter) Concept(c neo4j.ConceptNode) error {
+	if c, ok := sw.scrub.Concept(c); ok {
+		return sw.w.Concept(c)
+	}
+	return nil
+}
+
+func (sw *scrubWriter) Relationship(r neo4j.Relationship) error {
+	if r, ok := sw.scrub.Relationship(r); ok {
+		return sw.w.Relationship(r)
+	}
+	return nil
+}
+
+func (sw *scrubWriter) Close() error {
+	return sw.w.Close()
+}
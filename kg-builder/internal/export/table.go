@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"kg-builder/internal/neo4j"
+)
+
+// Records selects what a table export writes.
+const (
+	RecordsAll           = "all"
+	RecordsConcepts      = "concepts"
+	RecordsRelationships = "relationships"
+)
+
+// jsonLines writes one JSON object per line, each with a "kind" of concept or relationship, for tools such as
+// pandas.read_json(lines=True).
+type jsonLines struct {
+	w       *bufio.Writer
+	records string
+}
+
+// NewJSONLines returns a GraphWriter writing the selected records as JSON Lines to w. Concepts are written with
+// their properties as fields.
+func NewJSONLines(w io.Writer, records string) GraphWriter {
+	return &jsonLines{w: bufio.NewWriter(w), records: records}
+}
+
+func (j *jsonLines) Concept(c neo4j.ConceptNode) error {
+	if j.records == RecordsRelationships {
+		return nil
+	}
+	record := make(map[string]interface{}, len(c.Properties)+2)
+	for k, v := range c.Properties {
+		record[k] = v
+	}
+	record["kind"] = "concept"
+	record["name"] = c.Name
+	return j.write(record)
+}
+
+func (j *jsonLines) Relationship(r neo4j.Relationship) error {
+	if j.records == RecordsConcepts {
+		return nil
+	}
+	return j.write(map[string]interface{}{"kind": "relationship", "from": r.From, "to": r.To, "type": r.Type,
+		"description": r.Description})
+}
+
+func (j *jsonLines) Close() error {
+	return j.w.Flush()
+}
+
+func (j *jsonLines) write(record map[string]interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	j.w.Write(data)
+	return j.w.WriteByte('\n')
+}
+
+// csvTable writes either concepts or relationships as CSV with a header row.
+type csvTable struct {
+	w       *csv.Writer
+	records string
+}
+
+// NewCSV returns a GraphWriter writing the concepts or the relationships of the graph, as selected by records, as
+// CSV to w. Lists are joined with "; ".
+func NewCSV(w io.Writer, records string) GraphWriter {
+	t := &csvTable{w: csv.NewWriter(w), records: records}
+	if records == RecordsConcepts {
+		t.w.Write([]string{"name", "tags", "aliases", "degree", "createdAt", "runId"})
+	} else {
+		t.w.Write([]string{"from", "to", "type", "description"})
+	}
+	return t
+}
+
+func (t *csvTable) Concept(c neo4j.ConceptNode) error {
+	if t.records != RecordsConcepts {
+		return nil
+	}
+	createdAt := ""
+	if at, ok := c.Properties["createdAt"].(time.Time); ok {
+		createdAt = at.Format(time.RFC3339)
+	}
+	degree := ""
+	if d, ok := c.Properties["degree"].(int64); ok {
+		degree = strconv.FormatInt(d, 10)
+	}
+	runID, _ := c.Properties["runId"].(string)
+	return t.w.Write([]string{c.Name, listProperty(c, "tags"), listProperty(c, "aliases"), degree, createdAt, runID})
+}
+
+func (t *csvTable) Relationship(r neo4j.Relationship) error {
+	if t.records != RecordsRelationships {
+		return nil
+	}
+	return t.w.Write([]string{r.From, r.To, r.Type, r.Description})
+}
+
+func (t *csvTable) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// withDegrees adds the degree of each concept to its properties before writing it.
+type withDegrees struct {
+	GraphWriter
+	degrees map[string]int64
+}
+
+// WithDegrees returns a GraphWriter adding the degree of each concept, its number of relationships, to the concepts
+// written to gw as a "degree" property.
+func WithDegrees(gw GraphWriter, degrees []neo4j.ConceptDegree) GraphWriter {
+	w := &withDegrees{GraphWriter: gw, degrees: make(map[string]int64, len(degrees))}
+	for _, d := range degrees {
+		w.degrees[d.Name] = d.Degree
+	}
+	return w
+}
+
+func (w *withDegrees) Concept(c neo4j.ConceptNode) error {
+	properties := make(map[string]interface{}, len(c.Properties)+1)
+	for k, v := range c.Properties {
+		properties[k] = v
+	}
+	properties["degree"] = w.degrees[c.Name]
+	return w.GraphWriter.Concept(neo4j.ConceptNode{Name: c.Name, Properties: properties})
+}
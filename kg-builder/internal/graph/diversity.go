@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"kg-builder/internal/models"
+	kgneo4j "kg-builder/internal/neo4j"
+	"kg-builder/internal/similarity"
+)
+
+// existingNeighbors returns the concepts the concept is already linked to, if validation or quality scoring needs them.
+func (gb *GraphBuilder) existingNeighbors(concept string) []string {
+	if gb.config.DiversityThreshold <= 0 && gb.config.MinExpansionQuality <= 0 {
+		return nil
+	}
+	neighbors, err := kgneo4j.GetRelatedConceptNames(gb.driver, concept)
+	if err != nil {
+		logger.Warn("Error getting existing neighbors, only comparing within the expansion", "concept", concept, "error", err)
+	}
+	return neighbors
+}
+
+// filterSimilarConcepts drops related concepts that are lexically too similar to the concept itself, to one of its
+// existing neighbors, or to a related concept accepted earlier in the same expansion.
+func (gb *GraphBuilder) filterSimilarConcepts(concept string, neighbors []string, relatedConcepts []models.Concept) []models.Concept {
+	threshold := gb.config.DiversityThreshold
+	if threshold <= 0 {
+		return relatedConcepts
+	}
+
+	known := append([]string{concept}, neighbors...)
+
+	accepted := make([]models.Concept, 0, len(relatedConcepts))
+	for _, rc := range relatedConcepts {
+		if match, score := mostSimilar(rc.Name, known); score >= threshold {
+			logger.Debug("Skipping related concept", "concept", concept, "related", rc.Name, "reason", "too similar", "similarTo", match, "similarity", score)
+			continue
+		}
+		accepted = append(accepted, rc)
+		known = append(known, rc.Name)
+	}
+	return accepted
+}
+
+// mostSimilar returns the name in names most similar to name, and its score.
+func mostSimilar(name string, names []string) (string, float64) {
+	best, bestScore := "", 0.0
+	for _, other := range names {
+		if score := similarity.Score(name, other); score > bestScore {
+			best, bestScore = other, score
+		}
+	}
+	return best, bestScore
+}
@@ -0,0 +1,126 @@
+package graph
+
+// Acceptance rates of LLM outputs watched for drift.
+const (
+	AcceptanceResponse   = "response"   // Expansion calls answered with related concepts rather than an error
+	AcceptanceValidation = "validation" // Related concepts passing validation
+)
+
+// DriftAlert describes an acceptance rate that dropped below its baseline, which usually means a model or prompt
+// regression.
+type DriftAlert struct {
+	Rate      string  `json:"rate"`
+	Current   float64 `json:"current"`  // Rate over the last Window outputs
+	Baseline  float64 `json:"baseline"` // Rate over the first full window of the run
+	Threshold float64 `json:"threshold"`
+	Window    int     `json:"window"`
+	LLMCalls  int     `json:"llmCalls"`
+	RunID     string  `json:"runId,omitempty"`
+}
+
+// acceptanceWindow keeps the outcomes of the most recent outputs of one kind and the baseline they are compared to.
+type acceptanceWindow struct {
+	outcomes    []bool
+	accepted    int
+	baseline    float64
+	hasBaseline bool
+	alerting    bool // An alert was raised and the rate has not recovered since
+}
+
+func (w *acceptanceWindow) add(accepted bool, size int) {
+	w.outcomes = append(w.outcomes, accepted)
+	if accepted {
+		w.accepted++
+	}
+	if len(w.outcomes) > size {
+		if w.outcomes[0] {
+			w.accepted--
+		}
+		w.outcomes = w.outcomes[1:]
+	}
+}
+
+// rate returns the share of accepted outputs over the window.
+func (w *acceptanceWindow) rate() float64 {
+	if len(w.outcomes) == 0 {
+		return 1
+	}
+	return float64(w.accepted) / float64(len(w.outcomes))
+}
+
+// recordAcceptance adds the outcomes of LLM outputs to the rate and returns an alert if it just dropped more than
+// GRAPH_DRIFT_THRESHOLD below its baseline. The caller must hold the mutex.
+func (gb *GraphBuilder) recordAcceptance(rate string, accepted, total int) *DriftAlert {
+	size := gb.config.DriftWindow
+	if size <= 0 || total == 0 {
+		return nil
+	}
+	if gb.acceptance == nil {
+		gb.acceptance = make(map[string]*acceptanceWindow)
+	}
+	w, ok := gb.acceptance[rate]
+	if !ok {
+		w = &acceptanceWindow{}
+		gb.acceptance[rate] = w
+	}
+	for i := 0; i < total; i++ {
+		w.add(i < accepted, size)
+	}
+	if len(w.outcomes) < size {
+		return nil
+	}
+
+	current := w.rate()
+	if !w.hasBaseline {
+		w.baseline, w.hasBaseline = current, true
+		logger.Info("Acceptance rate baseline", "rate", rate, "baseline", current, "outputs", size)
+		return nil
+	}
+	dropped := current < w.baseline-gb.config.DriftThreshold
+	if !dropped {
+		if w.alerting {
+			logger.Info("Acceptance rate recovered", "rate", rate, "current", current, "baseline", w.baseline)
+		}
+		w.alerting = false
+		return nil
+	}
+	if w.alerting {
+		return nil // Alert once per drop
+	}
+	w.alerting = true
+	gb.stats.DriftAlerts++
+	return &DriftAlert{
+		Rate:      rate,
+		Current:   current,
+		Baseline:  w.baseline,
+		Threshold: gb.config.DriftThreshold,
+		Window:    size,
+		LLMCalls:  gb.stats.LLMCalls,
+		RunID:     gb.stats.RunID,
+	}
+}
+
+// alertDrift logs the alert and posts it to the drift webhook.
+func (gb *GraphBuilder) alertDrift(alert *DriftAlert) {
+	if alert == nil {
+		return
+	}
+	logger.Warn("Acceptance rate dropped below its baseline; the model or prompts may have regressed",
+		"rate", alert.Rate, "current", alert.Current, "outputs", alert.Window, "baseline", alert.Baseline)
+	if gb.config.DriftWebhook != "" {
+		if err := postWebhook(gb.config.DriftWebhook, alert); err != nil {
+			logger.Error("Error calling the drift webhook", "error", err)
+		}
+	}
+}
+
+// logAcceptance logs the current acceptance rates and their baselines.
+func (gb *GraphBuilder) logAcceptance() {
+	gb.mutex.Lock()
+	defer gb.mutex.Unlock()
+	for _, rate := range []string{AcceptanceResponse, AcceptanceValidation} {
+		if w, ok := gb.acceptance[rate]; ok && w.hasBaseline {
+			logger.Info("Acceptance rate", "rate", rate, "current", w.rate(), "baseline", w.baseline)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"kg-builder/internal/models"
+)
+
+// Concept filters that can be enabled through GRAPH_CONCEPT_FILTERS.
+const (
+	FilterNumbers       = "numbers"        // Pure numbers, e.g. "42", "3.14" or "25%"
+	FilterDates         = "dates"          // Dates and decades, e.g. "2021-03-04", "March 2020" or "1990s"
+	FilterSingleLetters = "single-letters" // Names of a single character
+	FilterUnits         = "units"          // Measurement units and quantities, e.g. "kg" or "10 km"
+)
+
+var (
+	numberPattern = regexp.MustCompile(`^[-+]?(\d+([.,]\d+)*|[.,]\d+)%?$`)
+	datePatterns  = []*regexp.Regexp{
+		regexp.MustCompile(`^\d{4}-\d{1,2}(-\d{1,2})?$`),
+		regexp.MustCompile(`^\d{1,2}[/.-]\d{1,2}[/.-]\d{2,4}$`),
+		regexp.MustCompile(`^\d{4}'?s$`),
+		regexp.MustCompile(`^(\d{1,2}(st|nd|rd|th)? )?` + monthPattern + `( \d{1,2}(st|nd|rd|th)?,?)?( \d{4})?$`),
+	}
+	quantityPattern = regexp.MustCompile(`^[-+]?\d+([.,]\d+)* ?(\S+)$`)
+)
+
+// monthPattern matches month names and their abbreviations, in lower case.
+const monthPattern = `(jan(uary)?|feb(ruary)?|mar(ch)?|apr(il)?|may|june?|july?|aug(ust)?|sep(t(ember)?)?|oct(ober)?|nov(ember)?|dec(ember)?)\.?`
+
+// units are measurement units in lower case.
+var units = map[string]bool{
+	"mm": true, "cm": true, "m": true, "km": true, "in": true, "ft": true, "mi": true,
+	"mg": true, "g": true, "kg": true, "t": true, "lb": true, "lbs": true, "oz": true,
+	"ml": true, "l": true,
+	"ms": true, "s": true, "sec": true, "min": true, "h": true, "hr": true,
+	"hz": true, "khz": true, "mhz": true, "ghz": true,
+	"w": true, "kw": true, "mw": true, "kwh": true, "v": true, "a": true, "j": true, "kj": true, "cal": true, "kcal": true,
+	"°c": true, "°f": true, "k": true,
+	"b": true, "kb": true, "mb": true, "gb": true, "tb": true,
+	"meter": true, "meters": true, "kilometer": true, "kilometers": true, "gram": true, "grams": true,
+	"kilogram": true, "kilograms": true, "liter": true, "liters": true, "second": true, "seconds": true,
+	"minute": true, "minutes": true, "hour": true, "hours": true, "watt": true, "watts": true, "volt": true, "volts": true,
+}
+
+// ConceptFilter rejects related concepts that are not worth adding to the graph: the kinds enabled through
+// GRAPH_CONCEPT_FILTERS and the stop concepts listed in GRAPH_STOP_CONCEPTS_FILE.
+type ConceptFilter struct {
+	filters      map[string]bool
+	stopConcepts map[string]bool // Lower-case names
+}
+
+// NewConceptFilter returns a filter for the given kinds and stop concepts file, or nil if neither is set. The file
+// holds one concept per line; blank lines and lines starting with # are ignored.
+func NewConceptFilter(filters []string, stopConceptsFile string) (*ConceptFilter, error) {
+	if len(filters) == 0 && stopConceptsFile == "" {
+		return nil, nil
+	}
+	f := &ConceptFilter{filters: make(map[string]bool), stopConcepts: make(map[string]bool)}
+	for _, filter := range filters {
+		f.filters[filter] = true
+	}
+	if stopConceptsFile == "" {
+		return f, nil
+	}
+
+	file, err := os.Open(stopConceptsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stop concepts file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			f.stopConcepts[normalizeConceptName(line)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stop concepts file: %w", err)
+	}
+	logger.Info("Loaded stop concepts", "count", len(f.stopConcepts), "file", stopConceptsFile)
+	return f, nil
+}
+
+// Reject returns why the concept name is rejected, or an empty string if it is accepted.
+func (f *ConceptFilter) Reject(name string) string {
+	if f == nil {
+		return ""
+	}
+	normalized := normalizeConceptName(name)
+	switch {
+	case f.stopConcepts[normalized]:
+		return "stop concept"
+	case f.filters[FilterSingleLetters] && utf8.RuneCountInString(normalized) == 1:
+		return "single letter"
+	case f.filters[FilterNumbers] && numberPattern.MatchString(normalized):
+		return "number"
+	case f.filters[FilterDates] && isDate(normalized):
+		return "date"
+	case f.filters[FilterUnits] && isMeasurement(normalized):
+		return "measurement unit"
+	}
+	return ""
+}
+
+// SetConceptFilter makes the builder reject the related concepts the filter rejects.
+func (gb *GraphBuilder) SetConceptFilter(f *ConceptFilter) {
+	gb.conceptFilter = f
+}
+
+// filterInvalidConcepts drops the related concepts rejected by the concept filter.
+func (gb *GraphBuilder) filterInvalidConcepts(concept string, relatedConcepts []models.Concept) []models.Concept {
+	if gb.conceptFilter == nil {
+		return relatedConcepts
+	}
+	accepted := make([]models.Concept, 0, len(relatedConcepts))
+	for _, rc := range relatedConcepts {
+		if reason := gb.conceptFilter.Reject(rc.Name); reason != "" {
+			logger.Debug("Skipping related concept", "concept", concept, "related", rc.Name, "reason", reason)
+			continue
+		}
+		accepted = append(accepted, rc)
+	}
+	return accepted
+}
+
+// isDate reports whether the lower-case name is a date or a decade.
+func isDate(name string) bool {
+	for _, p := range datePatterns {
+		if p.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMeasurement reports whether the lower-case name is a measurement unit or a quantity in one.
+func isMeasurement(name string) bool {
+	if units[name] {
+		return true
+	}
+	m := quantityPattern.FindStringSubmatch(name)
+	return m != nil && units[m[2]]
+}
+
+// normalizeConceptName lower-cases the name and collapses its whitespace.
+func normalizeConceptName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
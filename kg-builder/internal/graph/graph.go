@@ -2,54 +2,114 @@ package graph
 
 import (
 	"context"
-	"log"
+	"errors"
 	"math/rand" // Keep this import as we'll use it in getRandomPair
 	"sync"
 	"time"
 
+	"kg-builder/internal/breaker"
+	"kg-builder/internal/config"
+	"kg-builder/internal/logging"
 	"kg-builder/internal/models"
 	kgneo4j "kg-builder/internal/neo4j"
+	"kg-builder/internal/screen"
+	"kg-builder/internal/wal"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
 
-const maxNodes = 100
+// logger is the logger of the graph module.
+var logger = logging.For("graph")
 
 // GraphBuilder struct
 type GraphBuilder struct {
 	driver             neo4j.Driver
+	config             config.GraphConfig
 	getRelatedConcepts func(string) ([]models.Concept, error)
 	mineRelationship   func(string, string) (*models.Concept, error)
 	processedConcepts  map[string]bool
+	seenConcepts       map[string]bool // Concepts processed, queued or returned by an expansion
 	nodeCount          int
+	maxNodes           int
+	pending            int // Concepts queued or being processed
+	novelty            noveltyWindow
+	stats              RunStats
+	metrics            *metricsRecorder
+	outage             outageMonitor
+	wal                *wal.Log // Nil when the write-ahead log is disabled
+	lastProgress       time.Time
+	rejectedAtProgress int // ConceptsRejected when progress was last made
+	acceptance         map[string]*acceptanceWindow
+	deferred           []workItem     // Related concepts of down-ranked expansions, queued once the frontier is empty
+	refused            []workItem     // Concepts the LLM circuit breaker refused, queued again once the LLM answers
+	conceptFilter      *ConceptFilter // Nil when no concept filter is configured
+	screener           *screen.Screener
+	reviewQueue        *screen.Queue
+	llmBreaker         *breaker.Breaker // Nil when the LLM has no circuit breaker
+	neo4jBreaker       *breaker.Breaker // Nil when the Neo4j writes have no circuit breaker
+	cancel             context.CancelFunc
 	mutex              sync.Mutex
 }
 
+// workItem is a concept in the frontier with the number of relationships separating it from the seed concept.
+type workItem struct {
+	Concept string
+	Depth   int
+}
+
 // NewGraphBuilder creates a new GraphBuilder instance
-func NewGraphBuilder(driver neo4j.Driver, getRelatedConcepts func(string) ([]models.Concept, error), mineRelationship func(string, string) (*models.Concept, error)) *GraphBuilder {
+func NewGraphBuilder(driver neo4j.Driver, cfg config.GraphConfig, getRelatedConcepts func(string) ([]models.Concept, error), mineRelationship func(string, string) (*models.Concept, error)) *GraphBuilder {
 	return &GraphBuilder{
 		driver:             driver,
+		config:             cfg,
 		getRelatedConcepts: getRelatedConcepts,
 		mineRelationship:   mineRelationship,
 		processedConcepts:  make(map[string]bool),
+		seenConcepts:       make(map[string]bool),
 		nodeCount:          0,
+		novelty:            noveltyWindow{size: cfg.NoveltyWindow},
+		metrics:            newMetricsRecorder(),
+		neo4jBreaker:       breaker.New("neo4j", cfg.Neo4jBreakerThreshold, cfg.Neo4jBreakerCooldown),
 	}
 }
 
-// BuildGraph builds the knowledge graph
+// BuildGraph builds the knowledge graph until one of the enabled stop conditions is met
 func (gb *GraphBuilder) BuildGraph(seedConcept string, maxNodes int, timeout time.Duration) error {
+	if kgneo4j.ReadOnly() {
+		return kgneo4j.ErrReadOnly
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	queue := make(chan string, maxNodes) // Create a channel to hold concepts
-	queue <- seedConcept                 // Add the seed concept to the queue
+	gb.mutex.Lock()
+	gb.maxNodes = maxNodes
+	gb.cancel = cancel
+	gb.stats.RunID = logging.RunID()
+	gb.stats.StartedAt = time.Now()
+	gb.markProgress()
+	gb.mutex.Unlock()
+
+	queue := make(chan workItem, maxNodes) // Create a channel to hold concepts
+	gb.mutex.Lock()
+	gb.seenConcepts[seedConcept] = true
+	gb.enqueue(queue, workItem{Concept: seedConcept}) // Add the seed concept to the queue
+	gb.mutex.Unlock()
 
 	var wg sync.WaitGroup
 	workerCount := 10 // Adjust this number based on your needs and system capabilities
+	nextWorker := 0
 
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go gb.worker(ctx, &wg, queue)
+	startWorkers := func() { // Also called by the watchdog to replace stuck workers
+		for i := 0; i < workerCount; i++ {
+			wg.Add(1)
+			go gb.worker(ctx, &wg, nextWorker, queue)
+			nextWorker++
+		}
+	}
+	startWorkers()
+	if gb.config.StallTimeout > 0 {
+		go gb.watch(ctx, queue, startWorkers)
 	}
 
 	done := make(chan struct{})
@@ -61,77 +121,304 @@ func (gb *GraphBuilder) BuildGraph(seedConcept string, maxNodes int, timeout tim
 	select {
 	case <-ctx.Done():
 		if ctx.Err() == context.DeadlineExceeded {
-			log.Printf("Timeout reached after processing %d concepts", gb.nodeCount)
+			gb.stop(StopTimeout)
 		}
 	case <-done:
-		log.Printf("Graph building completed, processed %d concepts", gb.nodeCount)
+	}
+
+	stats := gb.Stats()
+	logger.Info("Graph building stopped", "reason", stats.StopReason, "concepts", stats.ConceptsProcessed,
+		"relationships", stats.RelationshipsCreated, "llmCalls", stats.LLMCalls, "conceptsRefused", stats.ConceptsRefused,
+		"llmBreakerTrips", stats.LLMBreaker.Trips, "neo4jBreakerTrips", stats.Neo4jBreaker.Trips,
+		"neo4jOutages", stats.Neo4jOutages)
+	gb.Metrics().logSummary(time.Since(stats.StartedAt))
+	gb.logAcceptance()
+	if pending, dropped := gb.pendingWrites(); len(pending) > 0 || dropped > 0 {
+		logger.Error("Buffered relationships were not written because Neo4j did not come back in time", "pending", len(pending), "dropped", dropped)
 	}
 
 	return nil
 }
 
-func (gb *GraphBuilder) worker(ctx context.Context, wg *sync.WaitGroup, queue chan string) {
+// Metrics returns the per-phase timing histograms and per-worker activity collected so far
+func (gb *GraphBuilder) Metrics() Metrics {
+	return gb.metrics.snapshot()
+}
+
+// Stats returns the statistics of the current or last run
+func (gb *GraphBuilder) Stats() RunStats {
+	gb.mutex.Lock()
+	defer gb.mutex.Unlock()
+	stats := gb.stats
+	stats.ConceptsProcessed = gb.nodeCount
+	stats.ConceptsRefused = len(gb.refused)
+	stats.LLMBreaker = gb.llmBreaker.Stats()
+	stats.Neo4jBreaker = gb.neo4jBreaker.Stats()
+	return stats
+}
+
+func (gb *GraphBuilder) worker(ctx context.Context, wg *sync.WaitGroup, id int, queue chan workItem) {
 	defer wg.Done()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case concept, ok := <-queue:
+		case item, ok := <-queue:
 			if !ok {
 				return
 			}
-
-			gb.mutex.Lock()
-			if gb.processedConcepts[concept] || gb.nodeCount >= maxNodes {
-				gb.mutex.Unlock()
-				continue
+			if !gb.waitForNeo4j(ctx) || !gb.neo4jBreaker.Wait(ctx) { // Pause while Neo4j is unavailable
+				return
 			}
-			gb.processedConcepts[concept] = true
-			gb.nodeCount++
-			currentNodeCount := gb.nodeCount
-			gb.mutex.Unlock()
+			if !gb.llmBreaker.Wait(ctx) { // and while the LLM circuit breaker is open
+				return
+			}
+			gb.processConcept(ctx, id, queue, item)
+			gb.finishConcept(queue)
+		}
+	}
+}
 
-			log.Printf("Processing concept: %s (Node count: %d)", concept, currentNodeCount)
+// processConcept expands a single concept and queues its related concepts
+func (gb *GraphBuilder) processConcept(ctx context.Context, worker int, queue chan workItem, item workItem) {
+	concept := item.Concept
+	gb.mutex.Lock()
+	if gb.processedConcepts[concept] {
+		gb.mutex.Unlock()
+		return
+	}
+	if gb.nodeCount >= gb.maxNodes {
+		gb.mutex.Unlock()
+		gb.stop(StopMaxNodes)
+		return
+	}
+	if gb.budgetExhausted() {
+		gb.mutex.Unlock()
+		gb.stop(StopBudget)
+		return
+	}
+	gb.processedConcepts[concept] = true
+	gb.nodeCount++
+	gb.stats.LLMCalls++
+	currentNodeCount := gb.nodeCount
+	gb.mutex.Unlock()
+
+	logger.Info("Processing concept", "concept", concept, "nodeCount", currentNodeCount, "depth", item.Depth)
+	timer := newConceptTimer()
+	defer func() {
+		gb.metrics.record(worker, timer)
+		logger.Debug("Processed concept", "concept", concept, "timings", timer.String())
+	}()
 
-			relatedConcepts, err := gb.getRelatedConcepts(concept)
-			if err != nil {
-				log.Printf("Error getting related concepts for %s: %v", concept, err)
-				continue
+	var relatedConcepts []models.Concept
+	var err error
+	timer.track(PhaseLLM, func() {
+		relatedConcepts, err = gb.getRelatedConcepts(concept)
+	})
+	if errors.Is(err, breaker.ErrOpen) {
+		// The LLM is down: hold the concept back to expand it once the breaker lets requests through again. It is
+		// not put back in the frontier, which the other workers may have filled.
+		gb.mutex.Lock()
+		delete(gb.processedConcepts, concept)
+		gb.nodeCount--
+		gb.stats.LLMCalls--
+		gb.refused = append(gb.refused, item)
+		gb.mutex.Unlock()
+		return
+	}
+	if err == nil {
+		gb.mutex.Lock()
+		gb.requeueRefused(queue) // The LLM answers again
+		gb.mutex.Unlock()
+	}
+	if err != nil {
+		logger.Error("Error getting related concepts", "concept", concept, "error", err)
+		gb.mutex.Lock()
+		alert := gb.recordAcceptance(AcceptanceResponse, 0, 1)
+		gb.mutex.Unlock()
+		gb.alertDrift(alert)
+		return
+	}
+
+	logger.Debug("Found related concepts", "concept", concept, "count", len(relatedConcepts))
+	novelty, deferred := false, false
+	timer.track(PhaseValidation, func() {
+		found := len(relatedConcepts)
+		neighbors := gb.existingNeighbors(concept)
+		deferred = gb.downRank(concept, neighbors, relatedConcepts)
+		relatedConcepts = gb.filterInvalidConcepts(concept, relatedConcepts)
+		relatedConcepts = gb.filterSimilarConcepts(concept, neighbors, relatedConcepts)
+		gb.mutex.Lock()
+		gb.stats.ConceptsRejected += found - len(relatedConcepts)
+		responseAlert := gb.recordAcceptance(AcceptanceResponse, 1, 1)
+		validationAlert := gb.recordAcceptance(AcceptanceValidation, len(relatedConcepts), found)
+		gb.mutex.Unlock()
+		gb.alertDrift(responseAlert)
+		gb.alertDrift(validationAlert)
+		novelty = gb.recordNovelty(relatedConcepts)
+	})
+	if novelty {
+		gb.stop(StopNovelty)
+	}
+
+	timer.track(PhaseNeo4j, func() { gb.writeRelationships(ctx, queue, item, relatedConcepts, deferred) })
+}
+
+// writeRelationships stores the relationships from the expanded concept to its related concepts and queues the ones
+// not processed yet, or defers them if the expansion was down-ranked. Relationships flagged by screening are held back
+// for review. They are written in one batch; if that fails they are written one by one, so one bad relationship does
+// not lose the others. Writes that fail because Neo4j is down are buffered until it is back.
+func (gb *GraphBuilder) writeRelationships(ctx context.Context, queue chan workItem, item workItem, relatedConcepts []models.Concept, deferred bool) {
+	concept := item.Concept
+	relatedConcepts = gb.holdForReview(concept, relatedConcepts)
+	writes := make([]pendingWrite, len(relatedConcepts))
+	for i, rc := range relatedConcepts {
+		writes[i] = pendingWrite{From: concept, To: rc.Name, Relation: rc.Relation, WALID: gb.walAppend(concept, rc.Name, rc.Relation), Depth: item.Depth + 1, Deferred: deferred}
+	}
+
+	if len(writes) > 1 && !gb.outage.isDown() {
+		logger.Debug("Creating relationships", "concept", concept, "count", len(writes))
+		err := gb.neo4jWrite(func() error { return kgneo4j.CreateRelationshipsBatch(gb.driver, batchOf(writes)) })
+		if err == nil {
+			for _, w := range writes {
+				gb.relationshipWritten(queue, w)
 			}
+			return
+		}
+		logger.Warn("Error creating relationships in one batch, writing them one by one", "concept", concept, "error", err)
+	}
 
-			log.Printf("Found %d related concepts for %s", len(relatedConcepts), concept)
-			for _, rc := range relatedConcepts {
-				gb.mutex.Lock()
-				if gb.nodeCount >= maxNodes {
-					gb.mutex.Unlock()
-					return
-				}
-				gb.mutex.Unlock()
+	for _, w := range writes {
+		var err error
+		if gb.outage.isDown() {
+			err = errNeo4jUnavailable // Do not hammer Neo4j while it is known to be down
+		} else {
+			logger.Debug("Creating relationship", "from", w.From, "relation", w.Relation, "to", w.To)
+			err = gb.neo4jWrite(func() error { return kgneo4j.CreateRelationship(gb.driver, w.From, w.To, w.Relation) })
+		}
 
-				log.Printf("Creating relationship: %s -[%s]-> %s", concept, rc.Relation, rc.Name)
-				err := kgneo4j.CreateRelationship(gb.driver, concept, rc.Name, rc.Relation)
-				if err != nil {
-					log.Printf("Error creating relationship: %v", err)
-					continue
-				}
-				log.Printf("Successfully created relationship: %s -[%s]-> %s", concept, rc.Relation, rc.Name)
-
-				gb.mutex.Lock()
-				if !gb.processedConcepts[rc.Name] && gb.nodeCount < maxNodes {
-					select {
-					case queue <- rc.Name:
-					default:
-						// Queue is full, skip this concept
-					}
-				}
-				gb.mutex.Unlock()
+		switch {
+		case err == nil:
+			gb.relationshipWritten(queue, w)
+		case gb.handleWriteError(ctx, w, err):
+			logger.Info("Buffered relationship until Neo4j is back", "from", w.From, "relation", w.Relation, "to", w.To)
+			gb.mutex.Lock()
+			gb.enqueueRelated(queue, w)
+			gb.mutex.Unlock()
+		default:
+			logger.Error("Error creating relationship", "from", w.From, "relation", w.Relation, "to", w.To, "error", err)
+			if !gb.outage.isDown() {
+				gb.walCommit(w.WALID) // Retrying an ordinary error on the next run would fail again
 			}
 		}
 	}
 }
 
+// relationshipWritten records a successful write and queues the related concept.
+func (gb *GraphBuilder) relationshipWritten(queue chan workItem, w pendingWrite) {
+	logger.Debug("Created relationship", "from", w.From, "relation", w.Relation, "to", w.To)
+	gb.walCommit(w.WALID)
+	gb.mutex.Lock()
+	defer gb.mutex.Unlock()
+	gb.stats.RelationshipsCreated++
+	gb.markProgress()
+	gb.enqueueRelated(queue, w)
+}
+
+// enqueueRelated queues the related concept of a write, or defers it if its expansion was down-ranked. Concepts at
+// GRAPH_MAX_DEPTH are left unexpanded. The caller must hold the mutex.
+func (gb *GraphBuilder) enqueueRelated(queue chan workItem, w pendingWrite) {
+	item := workItem{Concept: w.To, Depth: w.Depth}
+	switch {
+	case gb.config.MaxDepth > 0 && item.Depth >= gb.config.MaxDepth:
+		gb.stats.ConceptsAtMaxDepth++
+	case w.Deferred:
+		gb.deferred = append(gb.deferred, item)
+	default:
+		gb.enqueueUnprocessed(queue, item)
+	}
+}
+
+// enqueueUnprocessed queues the concept unless it was processed or the node limit is reached. The caller must hold the mutex.
+func (gb *GraphBuilder) enqueueUnprocessed(queue chan workItem, item workItem) {
+	if !gb.processedConcepts[item.Concept] && gb.nodeCount < gb.maxNodes {
+		gb.enqueue(queue, item)
+	}
+}
+
+// requeueRefused puts the concepts the LLM circuit breaker refused back in the frontier, as far as it has room; the
+// others stay held back. The caller must hold the mutex.
+func (gb *GraphBuilder) requeueRefused(queue chan workItem) {
+	if len(gb.refused) == 0 {
+		return
+	}
+	requeued := 0
+	for ; requeued < len(gb.refused) && len(queue) < cap(queue); requeued++ {
+		gb.enqueueUnprocessed(queue, gb.refused[requeued])
+	}
+	logger.Info("Queueing concepts refused by the LLM circuit breaker again", "concepts", requeued,
+		"held", len(gb.refused)-requeued)
+	gb.refused = append(gb.refused[:0], gb.refused[requeued:]...)
+}
+
+// batchOf converts writes to the relationships written by CreateRelationshipsBatch.
+func batchOf(writes []pendingWrite) []kgneo4j.Relationship {
+	relationships := make([]kgneo4j.Relationship, len(writes))
+	for i, w := range writes {
+		relationships[i] = kgneo4j.Relationship{From: w.From, To: w.To, Type: w.Relation}
+	}
+	return relationships
+}
+
+// enqueue adds a concept to the frontier without blocking. The caller must hold the mutex.
+func (gb *GraphBuilder) enqueue(queue chan workItem, item workItem) {
+	select {
+	case queue <- item:
+		gb.pending++
+	default:
+		// Queue is full, skip this concept
+	}
+}
+
+// finishConcept marks a dequeued concept as done and stops the build if the frontier is exhausted
+func (gb *GraphBuilder) finishConcept(queue chan workItem) {
+	gb.mutex.Lock()
+	gb.pending--
+	if gb.pending == 0 {
+		gb.requeueRefused(queue) // The workers wait for the breaker before expanding them
+	}
+	if gb.pending == 0 && len(gb.deferred) > 0 {
+		gb.enqueueDeferred(queue)
+	}
+	empty := gb.pending == 0
+	full := gb.nodeCount >= gb.maxNodes
+	gb.mutex.Unlock()
+
+	switch {
+	case empty && full:
+		gb.stop(StopMaxNodes)
+	case empty && gb.stopConditionEnabled(StopConditionFrontierEmpty):
+		gb.stop(StopFrontierEmpty)
+	}
+}
+
 func (gb *GraphBuilder) MineRandomRelationships(count int, concurrency int) {
+	if kgneo4j.ReadOnly() {
+		logger.Warn("Skipping random relationship mining", "error", kgneo4j.ErrReadOnly)
+		return
+	}
+
+	var tagged []string // Concepts to pick pairs from instead of the processed ones, when mining is limited to a tag
+	if gb.config.MineTag != "" {
+		var err error
+		if tagged, err = kgneo4j.ConceptsWithTag(gb.driver, gb.config.MineTag); err != nil {
+			logger.Error("Error getting the tagged concepts", "tag", gb.config.MineTag, "error", err)
+			return
+		}
+		logger.Info("Mining relationships among tagged concepts", "concepts", len(tagged), "tag", gb.config.MineTag)
+	}
+
 	semaphore := make(chan struct{}, concurrency)
 	var wg sync.WaitGroup
 
@@ -142,30 +429,45 @@ func (gb *GraphBuilder) MineRandomRelationships(count int, concurrency int) {
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			concepts := gb.getRandomPair()
+			var concepts [2]string
+			if gb.config.MineTag != "" {
+				concepts = randomPair(tagged)
+			} else {
+				concepts = gb.getRandomPair()
+			}
 			if concepts[0] == concepts[1] {
 				return
 			}
 
-			log.Printf("Mining relationship between %s and %s", concepts[0], concepts[1])
+			logger.Debug("Mining relationship", "from", concepts[0], "to", concepts[1])
 			concept, err := gb.mineRelationship(concepts[0], concepts[1])
 			if err != nil {
-				log.Printf("Error mining relationship: %v", err)
+				logger.Error("Error mining relationship", "from", concepts[0], "to", concepts[1], "error", err)
 				return
 			}
 
 			if concept == nil {
-				log.Printf("No relationship found between %s and %s", concepts[0], concepts[1])
+				logger.Debug("No relationship found", "from", concepts[0], "to", concepts[1])
+				return
+			}
+			if gb.screener != nil && gb.flagged(concepts[0], concepts[1], concept.Relation) {
 				return
 			}
 
-			log.Printf("Creating relationship: %s -[%s]-> %s", concepts[0], concept.Relation, concepts[1])
-			err = kgneo4j.CreateRelationship(gb.driver, concepts[0], concepts[1], concept.Relation)
+			logger.Debug("Creating relationship", "from", concepts[0], "relation", concept.Relation, "to", concepts[1])
+			walID := gb.walAppend(concepts[0], concepts[1], concept.Relation)
+			err = gb.neo4jWrite(func() error {
+				return kgneo4j.CreateRelationship(gb.driver, concepts[0], concepts[1], concept.Relation)
+			})
 			if err != nil {
-				log.Printf("Error creating relationship: %v", err)
+				logger.Error("Error creating relationship", "from", concepts[0], "relation", concept.Relation, "to", concepts[1], "error", err)
+				if !errors.Is(err, breaker.ErrOpen) && gb.driver.VerifyConnectivity() == nil {
+					gb.walCommit(walID) // Only keep the answer for the next run if Neo4j was unavailable
+				}
 				return
 			}
-			log.Printf("Successfully created relationship: %s -[%s]-> %s", concepts[0], concept.Relation, concepts[1])
+			gb.walCommit(walID)
+			logger.Info("Mined relationship", "from", concepts[0], "relation", concept.Relation, "to", concepts[1])
 		}()
 	}
 
@@ -180,7 +482,11 @@ func (gb *GraphBuilder) getRandomPair() [2]string {
 	for concept := range gb.processedConcepts {
 		concepts = append(concepts, concept)
 	}
+	return randomPair(concepts)
+}
 
+// randomPair returns two different random concepts of the list, or two empty names if it has fewer than two.
+func randomPair(concepts []string) [2]string {
 	if len(concepts) < 2 {
 		return [2]string{"", ""}
 	}
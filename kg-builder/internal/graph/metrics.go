@@ -0,0 +1,206 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases of a concept expansion that are timed separately.
+const (
+	PhaseLLM        = "llm"        // Asking the LLM for related concepts
+	PhaseValidation = "validation" // Filtering and scoring the returned concepts
+	PhaseNeo4j      = "neo4j"      // Writing the relationships
+)
+
+// histogramBuckets are the upper bounds of the timing histogram buckets; the last bucket is unbounded.
+var histogramBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// Histogram counts durations in fixed buckets.
+type Histogram struct {
+	Counts []int // One count per bucket in histogramBuckets, plus one for longer durations
+	Count  int
+	Sum    time.Duration
+	Max    time.Duration
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{Counts: make([]int, len(histogramBuckets)+1)}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(histogramBuckets), func(i int) bool { return d <= histogramBuckets[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// Mean returns the average observed duration.
+func (h *Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket containing the q-th quantile, capped at the largest observed duration.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int(q*float64(h.Count-1)) + 1
+	seen := 0
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			if i < len(histogramBuckets) && histogramBuckets[i] < h.Max {
+				return histogramBuckets[i]
+			}
+			break
+		}
+	}
+	return h.Max
+}
+
+func (h *Histogram) String() string {
+	return fmt.Sprintf("count=%d mean=%s p50<=%s p95<=%s max=%s",
+		h.Count, h.Mean().Round(time.Millisecond), h.Quantile(0.5), h.Quantile(0.95), h.Max.Round(time.Millisecond))
+}
+
+// WorkerStats describes the activity of a single worker.
+type WorkerStats struct {
+	ConceptsProcessed int
+	Busy              time.Duration
+}
+
+// Metrics holds the timing metrics of a build.
+type Metrics struct {
+	Phases  map[string]*Histogram // Per-phase time spent per concept
+	Total   *Histogram            // Total time spent per concept
+	Workers map[int]*WorkerStats
+}
+
+// conceptTimer accumulates the time spent in each phase while processing one concept.
+type conceptTimer struct {
+	start  time.Time
+	phases map[string]time.Duration
+}
+
+func newConceptTimer() *conceptTimer {
+	return &conceptTimer{start: time.Now(), phases: make(map[string]time.Duration)}
+}
+
+// track runs fn and adds its duration to the phase.
+func (t *conceptTimer) track(phase string, fn func()) {
+	start := time.Now()
+	fn()
+	t.phases[phase] += time.Since(start)
+}
+
+func (t *conceptTimer) String() string {
+	return fmt.Sprintf("%s (llm %s, validation %s, neo4j %s)", time.Since(t.start).Round(time.Millisecond),
+		t.phases[PhaseLLM].Round(time.Millisecond), t.phases[PhaseValidation].Round(time.Millisecond), t.phases[PhaseNeo4j].Round(time.Millisecond))
+}
+
+// metricsRecorder aggregates concept timers into histograms.
+type metricsRecorder struct {
+	mutex   sync.Mutex
+	metrics Metrics
+}
+
+func newMetricsRecorder() *metricsRecorder {
+	return &metricsRecorder{metrics: Metrics{
+		Phases: map[string]*Histogram{
+			PhaseLLM:        newHistogram(),
+			PhaseValidation: newHistogram(),
+			PhaseNeo4j:      newHistogram(),
+		},
+		Total:   newHistogram(),
+		Workers: make(map[int]*WorkerStats),
+	}}
+}
+
+// record adds the timings of a processed concept to the histograms and to the worker's statistics.
+func (r *metricsRecorder) record(worker int, t *conceptTimer) {
+	total := time.Since(t.start)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for phase, h := range r.metrics.Phases {
+		h.observe(t.phases[phase])
+	}
+	r.metrics.Total.observe(total)
+
+	ws, ok := r.metrics.Workers[worker]
+	if !ok {
+		ws = &WorkerStats{}
+		r.metrics.Workers[worker] = ws
+	}
+	ws.ConceptsProcessed++
+	ws.Busy += total
+}
+
+// snapshot returns a deep copy of the metrics.
+func (r *metricsRecorder) snapshot() Metrics {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	copyHistogram := func(h *Histogram) *Histogram {
+		c := *h
+		c.Counts = append([]int(nil), h.Counts...)
+		return &c
+	}
+	m := Metrics{
+		Phases:  make(map[string]*Histogram, len(r.metrics.Phases)),
+		Total:   copyHistogram(r.metrics.Total),
+		Workers: make(map[int]*WorkerStats, len(r.metrics.Workers)),
+	}
+	for phase, h := range r.metrics.Phases {
+		m.Phases[phase] = copyHistogram(h)
+	}
+	for id, ws := range r.metrics.Workers {
+		c := *ws
+		m.Workers[id] = &c
+	}
+	return m
+}
+
+// logSummary logs the per-phase histograms and the utilization of each worker over the elapsed time.
+func (m Metrics) logSummary(elapsed time.Duration) {
+	logger.Info("Concept timings", "phase", "total", "timings", m.Total.String())
+	for _, phase := range []string{PhaseLLM, PhaseValidation, PhaseNeo4j} {
+		logger.Info("Concept timings", "phase", phase, "timings", m.Phases[phase].String())
+	}
+
+	ids := make([]int, 0, len(m.Workers))
+	for id := range m.Workers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		ws := m.Workers[id]
+		utilization := 0.0
+		if elapsed > 0 {
+			utilization = float64(ws.Busy) / float64(elapsed) * 100
+		}
+		parts = append(parts, fmt.Sprintf("#%d: %d concepts, %.0f%% busy", id, ws.ConceptsProcessed, utilization))
+	}
+	logger.Info("Worker activity", "workers", strings.Join(parts, "; "))
+}
@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"kg-builder/internal/breaker"
+	kgneo4j "kg-builder/internal/neo4j"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// errNeo4jUnavailable is used for writes that are not attempted because an outage is in progress.
+var errNeo4jUnavailable = errors.New("Neo4j outage in progress")
+
+// pendingWrite is a relationship that could not be written because Neo4j was unavailable.
+type pendingWrite struct {
+	From     string
+	To       string
+	Relation string
+	WALID    uint64 // Write-ahead log record, committed once the write succeeds
+	Depth    int    // Depth of To, one more than the expanded concept's
+	Deferred bool   // The expansion was down-ranked, so To is only queued once the frontier is empty
+}
+
+// outageMonitor pauses the workers while Neo4j is unreachable and buffers the writes that failed in the meantime.
+type outageMonitor struct {
+	mutex   sync.Mutex
+	down    bool
+	resumed chan struct{} // Closed when connectivity is restored
+	buffer  []pendingWrite
+	dropped int
+}
+
+// isDown reports whether an outage is in progress.
+func (m *outageMonitor) isDown() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.down
+}
+
+// waitForNeo4j blocks while Neo4j is unavailable. It returns false if the build was stopped in the meantime.
+func (gb *GraphBuilder) waitForNeo4j(ctx context.Context) bool {
+	gb.outage.mutex.Lock()
+	if !gb.outage.down {
+		gb.outage.mutex.Unlock()
+		return true
+	}
+	resumed := gb.outage.resumed
+	gb.outage.mutex.Unlock()
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// neo4jWrite runs a write through the Neo4j circuit breaker. While the breaker is open the write is not attempted and
+// an error wrapping breaker.ErrOpen is returned. Errors reported by the server itself, such as constraint violations,
+// show that Neo4j is up and do not count as failures.
+func (gb *GraphBuilder) neo4jWrite(write func() error) error {
+	if err := gb.neo4jBreaker.Allow(); err != nil {
+		return err
+	}
+	err := write()
+	if neo4j.IsNeo4jError(err) {
+		gb.neo4jBreaker.Record(nil)
+	} else {
+		gb.neo4jBreaker.Record(err)
+	}
+	return err
+}
+
+// handleWriteError decides whether a failed write is caused by an outage, or was refused by the open Neo4j circuit
+// breaker. If so, the write is buffered, the workers are paused and a recovery loop is started, and true is returned.
+// The relationship is then treated as written.
+func (gb *GraphBuilder) handleWriteError(ctx context.Context, write pendingWrite, err error) bool {
+	if !errors.Is(err, breaker.ErrOpen) && !gb.outage.isDown() && gb.driver.VerifyConnectivity() == nil {
+		return false // Neo4j is reachable, so this is an ordinary error
+	}
+
+	gb.outage.mutex.Lock()
+	defer gb.outage.mutex.Unlock()
+
+	if !gb.outage.down {
+		logger.Warn("Neo4j is unavailable, pausing workers until it is back", "error", err)
+		gb.outage.down = true
+		gb.outage.resumed = make(chan struct{})
+		gb.mutex.Lock()
+		gb.stats.Neo4jOutages++
+		gb.mutex.Unlock()
+		go gb.recoverFromOutage(ctx)
+	}
+	if len(gb.outage.buffer) >= gb.config.OutageBufferSize {
+		gb.outage.dropped++
+		logger.Error("Outage buffer full, dropping relationship (it stays in the write-ahead log, if enabled)", "from", write.From, "relation", write.Relation, "to", write.To)
+		return false
+	}
+	gb.outage.buffer = append(gb.outage.buffer, write)
+	return true
+}
+
+// recoverFromOutage polls Neo4j until it is reachable again, flushes the buffered writes and resumes the workers. The
+// flush goes through the Neo4j circuit breaker, so it waits for the cooldown and is the breaker's probe.
+func (gb *GraphBuilder) recoverFromOutage(ctx context.Context) {
+	ticker := time.NewTicker(gb.config.OutageRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := gb.driver.VerifyConnectivity(); err != nil {
+			logger.Warn("Neo4j still unavailable", "error", err)
+			continue
+		}
+		if gb.flushOutageBuffer() {
+			return
+		}
+	}
+}
+
+// flushOutageBuffer writes the buffered relationships in one batch. It resumes the workers and returns true once
+// the buffer is empty.
+func (gb *GraphBuilder) flushOutageBuffer() bool {
+	for {
+		gb.outage.mutex.Lock()
+		if len(gb.outage.buffer) == 0 {
+			gb.outage.down = false
+			close(gb.outage.resumed)
+			gb.outage.mutex.Unlock()
+			logger.Info("Neo4j is available again, resuming workers")
+			return true
+		}
+		writes := append([]pendingWrite(nil), gb.outage.buffer...)
+		gb.outage.mutex.Unlock()
+
+		err := gb.neo4jWrite(func() error { return kgneo4j.CreateRelationshipsBatch(gb.driver, batchOf(writes)) })
+		if err != nil {
+			logger.Warn("Error flushing buffered relationships, retrying later", "relationships", len(writes), "error", err)
+			return false
+		}
+
+		for _, w := range writes {
+			gb.walCommit(w.WALID)
+		}
+		gb.outage.mutex.Lock()
+		gb.outage.buffer = gb.outage.buffer[len(writes):] // Workers may have buffered more in the meantime
+		gb.outage.mutex.Unlock()
+		gb.mutex.Lock()
+		gb.stats.RelationshipsCreated += len(writes)
+		gb.markProgress()
+		gb.mutex.Unlock()
+	}
+}
+
+// pendingWrites returns the relationships still buffered and the number dropped because the buffer was full.
+func (gb *GraphBuilder) pendingWrites() ([]pendingWrite, int) {
+	gb.outage.mutex.Lock()
+	defer gb.outage.mutex.Unlock()
+	return append([]pendingWrite(nil), gb.outage.buffer...), gb.outage.dropped
+}
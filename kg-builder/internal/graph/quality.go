@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"strings"
+
+	"kg-builder/internal/models"
+	kgneo4j "kg-builder/internal/neo4j"
+)
+
+// genericRelations are relation types that say nothing about how two concepts are related.
+var genericRelations = map[string]bool{
+	kgneo4j.DefaultRelationType: true,
+	"Related":                   true,
+	"IsRelatedTo":               true,
+	"AssociatedWith":            true,
+	"ConnectedTo":               true,
+	"LinkedTo":                  true,
+}
+
+// ExpansionQuality scores the related concepts the LLM returned for a concept. Each score is between 0 and 1.
+type ExpansionQuality struct {
+	Distinctness float64 // Share of returned concepts that are neither repeated nor the expanded concept itself
+	Specificity  float64 // Share of returned relations more specific than a generic "related to"
+	Novelty      float64 // Share of returned concepts not already linked to the expanded concept
+	Score        float64 // Mean of the three
+}
+
+// scoreExpansion scores the related concepts returned for concept, whose existing neighbors are given.
+func scoreExpansion(concept string, neighbors []string, relatedConcepts []models.Concept) ExpansionQuality {
+	if len(relatedConcepts) == 0 {
+		return ExpansionQuality{}
+	}
+	known := map[string]bool{}
+	for _, n := range neighbors {
+		known[strings.ToLower(n)] = true
+	}
+
+	seen := map[string]bool{strings.ToLower(concept): true}
+	distinct, specific, novel := 0, 0, 0
+	for _, rc := range relatedConcepts {
+		name := strings.ToLower(strings.TrimSpace(rc.Name))
+		if !seen[name] {
+			distinct++
+		}
+		seen[name] = true
+		if !genericRelations[kgneo4j.SanitizeRelationType(rc.Relation)] {
+			specific++
+		}
+		if !known[name] {
+			novel++
+		}
+	}
+
+	n := float64(len(relatedConcepts))
+	q := ExpansionQuality{Distinctness: float64(distinct) / n, Specificity: float64(specific) / n, Novelty: float64(novel) / n}
+	q.Score = (q.Distinctness + q.Specificity + q.Novelty) / 3
+	return q
+}
+
+// downRank reports whether an expansion is of too low quality for its related concepts to be expanded before the
+// others, logging its scores.
+func (gb *GraphBuilder) downRank(concept string, neighbors []string, relatedConcepts []models.Concept) bool {
+	if gb.config.MinExpansionQuality <= 0 || len(relatedConcepts) == 0 {
+		return false
+	}
+	q := scoreExpansion(concept, neighbors, relatedConcepts)
+	if q.Score >= gb.config.MinExpansionQuality {
+		return false
+	}
+	logger.Info("Down-ranking expansion", "concept", concept, "quality", q.Score,
+		"distinctness", q.Distinctness, "specificity", q.Specificity, "novelty", q.Novelty)
+	gb.mutex.Lock()
+	gb.stats.ExpansionsDownRanked++
+	gb.mutex.Unlock()
+	return true
+}
+
+// enqueueDeferred queues the concepts of down-ranked expansions, once nothing better is left. The caller must hold the mutex.
+func (gb *GraphBuilder) enqueueDeferred(queue chan workItem) {
+	queued := make(map[string]bool, len(gb.deferred))
+	for _, item := range gb.deferred {
+		if !queued[item.Concept] {
+			queued[item.Concept] = true
+			gb.enqueueUnprocessed(queue, item)
+		}
+	}
+	logger.Info("Frontier empty, expanding the concepts of down-ranked expansions", "concepts", len(queued))
+	gb.deferred = nil
+}
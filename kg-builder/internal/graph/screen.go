@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"kg-builder/internal/breaker"
+	"kg-builder/internal/models"
+	"kg-builder/internal/screen"
+)
+
+// SetScreening makes the builder hold back the relationships the screener flags, adding them to the review queue
+// instead of the graph.
+func (gb *GraphBuilder) SetScreening(s *screen.Screener, q *screen.Queue) {
+	gb.screener = s
+	gb.reviewQueue = q
+}
+
+// SetLLMBreaker makes the workers pause while the LLM circuit breaker is open, instead of failing the concepts.
+func (gb *GraphBuilder) SetLLMBreaker(b *breaker.Breaker) {
+	gb.llmBreaker = b
+}
+
+// holdForReview drops the related concepts whose name or relation the screener flags and queues them for review.
+func (gb *GraphBuilder) holdForReview(concept string, relatedConcepts []models.Concept) []models.Concept {
+	if gb.screener == nil {
+		return relatedConcepts
+	}
+	accepted := make([]models.Concept, 0, len(relatedConcepts))
+	for _, rc := range relatedConcepts {
+		if gb.flagged(concept, rc.Name, rc.Relation) {
+			continue
+		}
+		accepted = append(accepted, rc)
+	}
+	return accepted
+}
+
+// flagged reports whether the screener flags the relationship, queueing it for review if so. Relationships that
+// cannot be queued are dropped all the same, as storing them is what screening is there to prevent.
+func (gb *GraphBuilder) flagged(from, to, relation string) bool {
+	reason := gb.screener.Check(to)
+	if reason == "" {
+		reason = gb.screener.Check(relation)
+	}
+	if reason == "" {
+		return false
+	}
+
+	gb.mutex.Lock()
+	gb.stats.RelationshipsFlagged++
+	gb.mutex.Unlock()
+	if gb.reviewQueue == nil {
+		logger.Warn("Dropping flagged relationship", "from", from, "reason", reason)
+		return true
+	}
+	item, err := gb.reviewQueue.Add(from, to, relation, reason)
+	if err != nil {
+		logger.Error("Error queueing flagged relationship for review, dropping it", "from", from, "error", err)
+		return true
+	}
+	logger.Warn("Holding relationship back for review", "from", from, "item", item.ID, "reason", reason)
+	return true
+}
@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"time"
+
+	"kg-builder/internal/breaker"
+	"kg-builder/internal/models"
+)
+
+// Stop conditions that can be enabled through GRAPH_STOP_CONDITIONS, in addition to the node limit and the timeout.
+const (
+	StopConditionNovelty       = "novelty"        // Stop when most returned concepts are already known
+	StopConditionBudget        = "budget"         // Stop when the LLM call budget is spent
+	StopConditionFrontierEmpty = "frontier-empty" // Stop when there is nothing left to expand
+)
+
+// Reasons recorded in RunStats.StopReason.
+const (
+	StopMaxNodes      = "max nodes reached"
+	StopTimeout       = "timeout reached"
+	StopNovelty       = "novelty rate below threshold"
+	StopBudget        = "LLM budget exhausted"
+	StopFrontierEmpty = "frontier empty"
+	StopStalled       = "no progress"
+)
+
+// RunStats summarizes a BuildGraph run.
+type RunStats struct {
+	RunID                string // Correlation ID of the run in the logs, LLM cache entries and Neo4j provenance
+	StartedAt            time.Time
+	StoppedAt            time.Time
+	StopReason           string
+	ConceptsProcessed    int
+	RelationshipsCreated int
+	LLMCalls             int
+	ConceptsRejected     int     // Related concepts rejected by validation
+	DriftAlerts          int     // Times an acceptance rate dropped below its baseline
+	ExpansionsDownRanked int     // Expansions scoring below GRAPH_MIN_EXPANSION_QUALITY
+	ConceptsAtMaxDepth   int     // Related concepts left unexpanded because they are at GRAPH_MAX_DEPTH
+	RelationshipsFlagged int     // Relationships held back for review by screening
+	ConceptsRefused      int     // Concepts refused by the LLM circuit breaker and not expanded before the build stopped
+	Neo4jOutages         int     // Times the workers were paused because Neo4j was unavailable
+	NoveltyRate          float64 // Share of recently returned concepts that were new, over the novelty window
+
+	LLMBreaker   breaker.Stats // State of the LLM circuit breaker
+	Neo4jBreaker breaker.Stats // State of the Neo4j circuit breaker
+}
+
+// noveltyWindow keeps the number of new and total concepts returned by the most recent expansions.
+type noveltyWindow struct {
+	size    int
+	samples [][2]int // {new, total} per expansion
+}
+
+func (w *noveltyWindow) add(newConcepts, total int) {
+	w.samples = append(w.samples, [2]int{newConcepts, total})
+	if len(w.samples) > w.size {
+		w.samples = w.samples[1:]
+	}
+}
+
+// rate returns the share of new concepts over the window, and whether the window is full.
+func (w *noveltyWindow) rate() (float64, bool) {
+	newConcepts, total := 0, 0
+	for _, s := range w.samples {
+		newConcepts += s[0]
+		total += s[1]
+	}
+	if total == 0 {
+		return 1, len(w.samples) >= w.size
+	}
+	return float64(newConcepts) / float64(total), len(w.samples) >= w.size
+}
+
+// stop records the reason and cancels the build. Only the first reason is kept.
+func (gb *GraphBuilder) stop(reason string) {
+	gb.mutex.Lock()
+	defer gb.mutex.Unlock()
+
+	if gb.stats.StopReason != "" {
+		return
+	}
+	gb.stats.StopReason = reason
+	gb.stats.StoppedAt = time.Now()
+	logger.Info("Stopping graph building", "reason", reason)
+	if gb.cancel != nil {
+		gb.cancel()
+	}
+}
+
+// stopConditionEnabled reports whether the optional stop condition is configured.
+func (gb *GraphBuilder) stopConditionEnabled(condition string) bool {
+	for _, c := range gb.config.StopConditions {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// budgetExhausted reports whether the LLM call budget is spent. The caller must hold the mutex.
+func (gb *GraphBuilder) budgetExhausted() bool {
+	return gb.stopConditionEnabled(StopConditionBudget) && gb.config.LLMBudget > 0 && gb.stats.LLMCalls >= gb.config.LLMBudget
+}
+
+// recordNovelty marks the related concepts as seen, updates the novelty window and reports whether the novelty
+// stop condition is met.
+func (gb *GraphBuilder) recordNovelty(relatedConcepts []models.Concept) bool {
+	gb.mutex.Lock()
+	defer gb.mutex.Unlock()
+
+	newConcepts := 0
+	for _, rc := range relatedConcepts {
+		if !gb.seenConcepts[rc.Name] {
+			gb.seenConcepts[rc.Name] = true
+			newConcepts++
+		}
+	}
+	gb.novelty.add(newConcepts, len(relatedConcepts))
+
+	rate, full := gb.novelty.rate()
+	gb.stats.NoveltyRate = rate
+	return full && rate < gb.config.MinNovelty && gb.stopConditionEnabled(StopConditionNovelty)
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"fmt"
+
+	kgneo4j "kg-builder/internal/neo4j"
+	"kg-builder/internal/wal"
+)
+
+// walReplayBatchSize is the number of pending relationships written per transaction by ReplayWriteAheadLog.
+const walReplayBatchSize = 500
+
+// SetWriteAheadLog makes the builder record every relationship in the log before writing it to Neo4j
+func (gb *GraphBuilder) SetWriteAheadLog(w *wal.Log) {
+	gb.wal = w
+}
+
+// ReplayWriteAheadLog writes the relationships a previous run got from the LLM but did not commit to Neo4j,
+// and returns how many were written
+func (gb *GraphBuilder) ReplayWriteAheadLog() (int, error) {
+	if gb.wal == nil {
+		return 0, nil
+	}
+	if kgneo4j.ReadOnly() {
+		return 0, nil // Keep the relationships pending until writes are allowed again
+	}
+
+	pending := gb.wal.Pending()
+	replayed := 0
+	for start := 0; start < len(pending); start += walReplayBatchSize {
+		end := start + walReplayBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := make([]kgneo4j.Relationship, 0, end-start)
+		for _, r := range pending[start:end] {
+			batch = append(batch, kgneo4j.Relationship{From: r.From, To: r.To, Type: r.Relation})
+		}
+		if err := kgneo4j.CreateRelationshipsBatch(gb.driver, batch); err != nil {
+			return replayed, fmt.Errorf("failed to replay %d relationships: %w", len(batch), err)
+		}
+		for _, r := range pending[start:end] {
+			if err := gb.wal.Commit(r.ID); err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+	}
+	return replayed, nil
+}
+
+// walAppend records a relationship before it is written. Failures are logged, the write goes ahead regardless.
+func (gb *GraphBuilder) walAppend(from, to, relation string) uint64 {
+	if gb.wal == nil {
+		return 0
+	}
+	id, err := gb.wal.Append(from, to, relation)
+	if err != nil {
+		logger.Error("Error appending to write-ahead log", "error", err)
+	}
+	return id
+}
+
+// walCommit marks a relationship recorded by walAppend as written.
+func (gb *GraphBuilder) walCommit(id uint64) {
+	if gb.wal == nil {
+		return
+	}
+	if err := gb.wal.Commit(id); err != nil {
+		logger.Error("Error committing to write-ahead log", "error", err)
+	}
+}
@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"kg-builder/internal/breaker"
+)
+
+// Actions the watchdog takes when a build stalls, set through GRAPH_STALL_ACTION.
+const (
+	StallActionStop    = "stop"    // Stop the build
+	StallActionRestart = "restart" // Start a fresh set of workers, stopping the build once the restarts are used up
+	StallActionAlert   = "alert"   // Only log the stall and call the webhook
+)
+
+// webhookTimeout bounds a webhook call.
+const webhookTimeout = 10 * time.Second
+
+// StallReport describes the state of a build that made no progress for the stall timeout.
+type StallReport struct {
+	IdleSeconds          float64 `json:"idleSeconds"`
+	Cause                string  `json:"cause"`
+	Action               string  `json:"action"`
+	ConceptsProcessed    int     `json:"conceptsProcessed"`
+	RelationshipsCreated int     `json:"relationshipsCreated"`
+	LLMCalls             int     `json:"llmCalls"`
+	Queued               int     `json:"queued"`   // Concepts waiting in the frontier
+	InFlight             int     `json:"inFlight"` // Concepts taken by a worker and not finished
+	Rejected             int     `json:"rejected"` // Related concepts rejected by validation since the last progress
+	RunID                string  `json:"runId,omitempty"`
+}
+
+// markProgress records that the build created something, resetting the stall clock. The caller must hold the mutex.
+func (gb *GraphBuilder) markProgress() {
+	gb.lastProgress = time.Now()
+	gb.rejectedAtProgress = gb.stats.ConceptsRejected
+}
+
+// watch checks the progress of the build until ctx is done and acts on stalls. startWorkers starts a fresh set of workers.
+func (gb *GraphBuilder) watch(ctx context.Context, queue chan workItem, startWorkers func()) {
+	ticker := time.NewTicker(gb.config.StallTimeout / 4)
+	defer ticker.Stop()
+
+	restarts := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if gb.outage.isDown() || gb.llmBreaker.Stats().State != breaker.Closed ||
+			gb.neo4jBreaker.Stats().State != breaker.Closed {
+			gb.mutex.Lock()
+			gb.markProgress() // Workers pause on purpose during an outage
+			gb.mutex.Unlock()
+			continue
+		}
+
+		gb.mutex.Lock()
+		idle := time.Since(gb.lastProgress)
+		if idle < gb.config.StallTimeout {
+			gb.mutex.Unlock()
+			continue
+		}
+		report := gb.stallReport(idle, len(queue))
+		gb.markProgress() // Give the action a full timeout before the next report
+		gb.mutex.Unlock()
+
+		report.Action = gb.config.StallAction
+		if report.Action == StallActionRestart && restarts >= gb.config.StallRestarts {
+			report.Action = StallActionStop
+		}
+		logger.Warn("No progress", "idle", idle.Round(time.Millisecond), "cause", report.Cause, "queued", report.Queued,
+			"inFlight", report.InFlight, "rejected", report.Rejected, "action", report.Action)
+		if gb.config.StallWebhook != "" {
+			if err := postWebhook(gb.config.StallWebhook, report); err != nil {
+				logger.Error("Error calling the stall webhook", "error", err)
+			}
+		}
+
+		switch report.Action {
+		case StallActionStop:
+			gb.stop(StopStalled)
+			return
+		case StallActionRestart:
+			restarts++
+			logger.Warn("Starting a fresh set of workers", "restart", restarts, "restarts", gb.config.StallRestarts)
+			startWorkers()
+		}
+	}
+}
+
+// stallReport describes the current state of the build. The caller must hold the mutex.
+func (gb *GraphBuilder) stallReport(idle time.Duration, queued int) StallReport {
+	report := StallReport{
+		IdleSeconds:          idle.Seconds(),
+		ConceptsProcessed:    gb.nodeCount,
+		RelationshipsCreated: gb.stats.RelationshipsCreated,
+		LLMCalls:             gb.stats.LLMCalls,
+		Queued:               queued,
+		InFlight:             gb.pending - queued,
+		Rejected:             gb.stats.ConceptsRejected - gb.rejectedAtProgress,
+		RunID:                gb.stats.RunID,
+	}
+	switch {
+	case report.InFlight > 0:
+		report.Cause = "workers stuck, likely on a hung LLM call"
+	case report.Rejected > 0:
+		report.Cause = "related concepts rejected by validation"
+	case report.Queued == 0:
+		report.Cause = "frontier empty"
+	default:
+		report.Cause = "unknown"
+	}
+	return report
+}
+
+// postWebhook sends the payload as JSON to the webhook.
+func postWebhook(webhook string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
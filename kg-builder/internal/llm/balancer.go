@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// Balancing strategies, set through LLM_BALANCING.
+const (
+	BalancingRoundRobin  = "round-robin"  // Take the endpoints in turn
+	BalancingLeastLoaded = "least-loaded" // Take the endpoint with the fewest requests in flight
+)
+
+// endpoint is one Ollama server requests are balanced across.
+type endpoint struct {
+	url       string
+	inFlight  int
+	downUntil time.Time // The endpoint failed and is skipped until then
+}
+
+// balancer spreads requests over the Ollama endpoints, skipping the ones that failed recently.
+type balancer struct {
+	mutex     sync.Mutex
+	endpoints []*endpoint
+	strategy  string
+	cooldown  time.Duration
+	next      int // Next endpoint to try with round-robin
+}
+
+func newBalancer(urls []string, strategy string, cooldown time.Duration) *balancer {
+	b := &balancer{strategy: strategy, cooldown: cooldown}
+	for _, url := range urls {
+		b.endpoints = append(b.endpoints, &endpoint{url: url})
+	}
+	return b
+}
+
+// acquire picks an endpoint not in tried for a request and counts the request as in flight. Endpoints that failed
+// recently are only picked when all others did too, the one coming back first then. It returns nil when every
+// endpoint was tried.
+func (b *balancer) acquire(tried map[*endpoint]bool) *endpoint {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	var best, bestDown *endpoint
+	for i := range b.endpoints {
+		e := b.endpoints[(b.next+i)%len(b.endpoints)]
+		if tried[e] {
+			continue
+		}
+		if now.Before(e.downUntil) {
+			if bestDown == nil || e.downUntil.Before(bestDown.downUntil) {
+				bestDown = e
+			}
+			continue
+		}
+		if best == nil || (b.strategy == BalancingLeastLoaded && e.inFlight < best.inFlight) {
+			best = e
+		}
+	}
+	if best == nil {
+		best = bestDown
+	}
+	if best == nil {
+		return nil
+	}
+	b.next = (b.next + 1) % len(b.endpoints)
+	best.inFlight++
+	return best
+}
+
+// release ends a request on the endpoint. An endpoint whose request failed is skipped for the cooldown.
+func (b *balancer) release(e *endpoint, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	e.inFlight--
+	if !failed {
+		if !e.downUntil.IsZero() {
+			logger.Info("LLM endpoint is back", "endpoint", e.url)
+			e.downUntil = time.Time{}
+		}
+		return
+	}
+	if len(b.endpoints) > 1 && !time.Now().Before(e.downUntil) {
+		logger.Warn("LLM endpoint failed, skipping it", "endpoint", e.url, "cooldown", b.cooldown)
+	}
+	e.downUntil = time.Now().Add(b.cooldown)
+}
+
+// urls returns the URLs of all endpoints.
+func (b *balancer) urls() []string {
+	urls := make([]string, len(b.endpoints))
+	for i, e := range b.endpoints {
+		urls[i] = e.url
+	}
+	return urls
+}
@@ -0,0 +1,363 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"kg-builder/internal/models"
+)
+
+const (
+	cacheKindRelated      = "related" // Cached GetRelatedConcepts answers
+	cacheKindRelationship = "rel"     // Cached MineRelationship answers
+)
+
+// cacheStatsFile holds the cumulative lookup statistics of all runs, in the cache root.
+const cacheStatsFile = "stats.json"
+
+// promptVersion identifies the prompt templates. Bump it whenever a prompt changes so old answers are not reused.
+const promptVersion = "v2"
+
+// cachePartition identifies the set of answers that are interchangeable. Answers from a different
+// provider, model, prompt version or namespace are never returned.
+type cachePartition struct {
+	Provider      string `json:"provider"`
+	Model         string `json:"model"`
+	PromptVersion string `json:"promptVersion"`
+	Namespace     string `json:"namespace"`
+}
+
+// dir returns the partition directory relative to the cache root, e.g. ollama/llama3.1_latest/v2/default.
+func (p cachePartition) dir() string {
+	return filepath.Join(pathSegment(p.Provider), pathSegment(p.Model), pathSegment(p.PromptVersion), pathSegment(p.Namespace))
+}
+
+// cacheEntry is a single cached LLM answer stored as one JSON file.
+type cacheEntry struct {
+	cachePartition
+	Key      string           `json:"key"`
+	Negative bool             `json:"negative"` // True if the LLM found no relationship between the pair
+	StoredAt time.Time        `json:"storedAt"`
+	RunID    string           `json:"runId,omitempty"` // Run that asked the LLM, see logging.RunID
+	Concepts []models.Concept `json:"concepts,omitempty"`
+}
+
+// CacheStats counts cache lookups by outcome.
+type CacheStats struct {
+	Hits         int `json:"hits"`         // Lookups answered by a positive entry
+	NegativeHits int `json:"negativeHits"` // Lookups answered by a negative entry
+	Misses       int `json:"misses"`       // Lookups that had to call the LLM
+	Expired      int `json:"expired"`      // Entries found but discarded because their TTL had passed
+}
+
+// NegativeHitRate returns the fraction of lookups answered by a cached negative result.
+func (s CacheStats) NegativeHitRate() float64 {
+	lookups := s.Hits + s.NegativeHits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.NegativeHits) / float64(lookups)
+}
+
+// HitRate returns the fraction of lookups answered by the cache, positive or negative.
+func (s CacheStats) HitRate() float64 {
+	lookups := s.Hits + s.NegativeHits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.NegativeHits) / float64(lookups)
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("hits=%d negative_hits=%d misses=%d expired=%d negative_hit_rate=%.2f",
+		s.Hits, s.NegativeHits, s.Misses, s.Expired, s.NegativeHitRate())
+}
+
+// cacheBackend stores the answers of one cache partition.
+type cacheBackend interface {
+	get(kind, key string) (*cacheEntry, bool) // Returns the entry for the key, or false if it is missing or expired
+	put(kind string, entry cacheEntry) error  // Stores the entry, replacing any previous entry for the same key
+	flushStats() error                        // Persists the statistics of this run, if the backend keeps them
+	snapshot() CacheStats                     // Returns the statistics of this run
+}
+
+// expired reports whether the entry has outlived its TTL.
+func (e *cacheEntry) expired(ttl, negativeTTL time.Duration) bool {
+	if e.Negative {
+		ttl = negativeTTL
+	}
+	return time.Since(e.StoredAt) > ttl
+}
+
+// cacheCounter counts the lookups of a cache backend.
+type cacheCounter struct {
+	mutex sync.Mutex
+	stats CacheStats
+}
+
+// snapshot returns a copy of the current statistics.
+func (cc *cacheCounter) snapshot() CacheStats {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	return cc.stats
+}
+
+func (cc *cacheCounter) record(update func(*CacheStats)) {
+	cc.mutex.Lock()
+	update(&cc.stats)
+	cc.mutex.Unlock()
+}
+
+// recordHit counts a lookup answered by the entry.
+func (cc *cacheCounter) recordHit(entry *cacheEntry) {
+	if entry.Negative {
+		cc.record(func(s *CacheStats) { s.NegativeHits++ })
+	} else {
+		cc.record(func(s *CacheStats) { s.Hits++ })
+	}
+}
+
+// fileCache stores LLM answers as one JSON file per entry, with separate TTLs for positive and negative answers.
+// Entries live under root/<provider>/<model>/<prompt-version>/<namespace>/.
+type fileCache struct {
+	cacheCounter
+	root        string
+	dir         string
+	partition   cachePartition
+	ttl         time.Duration
+	negativeTTL time.Duration
+	budget      *cacheBudget // Shared by the partitions of a client; nil if the size is unlimited
+}
+
+// newFileCache creates the partition directory if needed, migrates entries written before partitioning
+// and returns a cache for the partition.
+func newFileCache(root string, partition cachePartition, ttl, negativeTTL time.Duration, budget *cacheBudget) (*fileCache, error) {
+	dir := filepath.Join(root, partition.dir())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+
+	fc := &fileCache{root: root, dir: dir, partition: partition, ttl: ttl, negativeTTL: negativeTTL, budget: budget}
+	if err := fc.migrateLegacyEntries(); err != nil {
+		return nil, fmt.Errorf("failed to migrate cache entries: %w", err)
+	}
+	return fc, nil
+}
+
+// get returns the cached entry for the key, or false if it is missing or expired.
+func (fc *fileCache) get(kind, key string) (*cacheEntry, bool) {
+	path := fc.path(kind, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fc.record(func(s *CacheStats) { s.Misses++ })
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key || entry.cachePartition != fc.partition {
+		fc.record(func(s *CacheStats) { s.Misses++ })
+		return nil, false
+	}
+
+	if entry.expired(fc.ttl, fc.negativeTTL) {
+		os.Remove(path)
+		fc.record(func(s *CacheStats) { s.Misses++; s.Expired++ })
+		return nil, false
+	}
+
+	fc.recordHit(&entry)
+	return &entry, true
+}
+
+// put writes the entry to disk, replacing any previous entry for the same key.
+func (fc *fileCache) put(kind string, entry cacheEntry) error {
+	entry.cachePartition = fc.partition
+	path := fc.path(kind, entry.Key)
+	if err := writeEntry(fc.dir, path, entry); err != nil {
+		return err
+	}
+	if fc.budget == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return fc.budget.add(info.Size())
+}
+
+// writeEntry atomically writes the entry to path, using dir for the temporary file.
+func writeEntry(dir, path string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partially written entry
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// cacheBudget keeps the file cache under its maximum size by evicting the oldest entries. Eviction brings the
+// cache down to 90% of the maximum, so it does not walk the cache on every write.
+type cacheBudget struct {
+	root    string
+	maxSize int64
+	mutex   sync.Mutex
+	size    int64 // Approximate; replaced entries are counted twice until the next eviction
+}
+
+// newCacheBudget measures the cache under root, evicting entries if it is already too large.
+func newCacheBudget(root string, maxSize int64) (*cacheBudget, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", root, err)
+	}
+	b := &cacheBudget{root: root, maxSize: maxSize}
+	result, err := EvictCache(root, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evict cache entries: %w", err)
+	}
+	b.size = result.RemainingBytes
+	return b, nil
+}
+
+// add accounts for an entry of the given size, evicting entries once the cache is too large.
+func (b *cacheBudget) add(size int64) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.size += size
+	if b.size <= b.maxSize {
+		return nil
+	}
+	result, err := EvictCache(b.root, b.maxSize*9/10)
+	if err != nil {
+		return fmt.Errorf("failed to evict cache entries: %w", err)
+	}
+	b.size = result.RemainingBytes
+	logger.Info("Evicted the oldest cache entries", "entries", result.Entries, "freedBytes", result.FreedBytes)
+	return nil
+}
+
+// flushStats adds the statistics of this run to the cumulative statistics stored in the cache root.
+func (fc *fileCache) flushStats() error {
+	run := fc.snapshot()
+
+	total, err := ReadCumulativeCacheStats(fc.root)
+	if err != nil {
+		return err
+	}
+	total.Hits += run.Hits
+	total.NegativeHits += run.NegativeHits
+	total.Misses += run.Misses
+	total.Expired += run.Expired
+
+	data, err := json.MarshalIndent(total, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(fc.root, cacheStatsFile), data, 0o644)
+}
+
+// path returns the file holding the entry, e.g. rel_<sha256>.json.
+func (fc *fileCache) path(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(fc.dir, kind+"_"+hex.EncodeToString(sum[:])+".json")
+}
+
+// migrateLegacyEntries moves entries written directly into the cache root, before the cache was partitioned,
+// into the partition matching the model recorded in each entry. Those entries were produced by the Ollama
+// provider with the first prompt version in the default namespace.
+func (fc *fileCache) migrateLegacyEntries() error {
+	files, err := os.ReadDir(fc.root)
+	if err != nil {
+		return err
+	}
+
+	migrated := 0
+	for _, file := range files {
+		if _, ok := entryKind(file.Name()); file.IsDir() || !ok {
+			continue
+		}
+
+		legacyPath := filepath.Join(fc.root, file.Name())
+		data, err := os.ReadFile(legacyPath)
+		if err != nil {
+			return err
+		}
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.Model == "" {
+			logger.Warn("Removing unreadable legacy cache entry", "path", legacyPath)
+			os.Remove(legacyPath)
+			continue
+		}
+
+		entry.cachePartition = cachePartition{
+			Provider:      "ollama",
+			Model:         entry.Model,
+			PromptVersion: "v1",
+			Namespace:     "default",
+		}
+		dir := filepath.Join(fc.root, entry.cachePartition.dir())
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := writeEntry(dir, filepath.Join(dir, file.Name()), entry); err != nil {
+			return err
+		}
+		if err := os.Remove(legacyPath); err != nil {
+			return err
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		logger.Info("Migrated legacy cache entries into partitioned directories", "entries", migrated)
+	}
+	return nil
+}
+
+// entryKind returns the kind of cache entry stored in the named file.
+func entryKind(name string) (string, bool) {
+	if !strings.HasSuffix(name, ".json") {
+		return "", false
+	}
+	for _, kind := range []string{cacheKindRelated, cacheKindRelationship} {
+		if strings.HasPrefix(name, kind+"_") {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// pathSegment makes a value safe to use as a single directory name.
+func pathSegment(value string) string {
+	if value == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', ' ':
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,363 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kg-builder/internal/models"
+)
+
+// CacheEntryInfo describes a cache entry on disk.
+type CacheEntryInfo struct {
+	Path          string
+	Kind          string
+	Provider      string
+	Model         string
+	PromptVersion string
+	Namespace     string
+	Key           string // Human readable key, "A -> B" for relationship entries
+	Negative      bool
+	StoredAt      time.Time
+	RunID         string // Run that asked the LLM; empty for entries stored before run IDs were recorded
+	Size          int64
+	Concepts      []models.Concept
+}
+
+// CacheFilter selects cache entries. Empty fields match everything.
+type CacheFilter struct {
+	Kind      string
+	Model     string
+	Namespace string
+	Pattern   string // Glob pattern matched against the human readable key
+	Negative  bool   // Only negative entries
+}
+
+// CacheSummary aggregates the entries of a cache directory.
+type CacheSummary struct {
+	Entries      int
+	Negative     int
+	Size         int64
+	ByKind       map[string]int
+	ByPartition  map[string]int
+	OldestStored time.Time
+	NewestStored time.Time
+	Lookups      CacheStats // Cumulative lookup statistics of all runs
+}
+
+// VacuumResult reports what VacuumCache removed.
+type VacuumResult struct {
+	Expired    int
+	Corrupt    int
+	TempFiles  int
+	EmptyDirs  int
+	FreedBytes int64
+}
+
+// EvictResult reports what EvictCache removed.
+type EvictResult struct {
+	Entries        int
+	FreedBytes     int64
+	RemainingBytes int64 // Size of the entries left
+}
+
+// ReadCumulativeCacheStats returns the lookup statistics accumulated over all runs using the cache root.
+func ReadCumulativeCacheStats(root string) (CacheStats, error) {
+	var stats CacheStats
+	data, err := os.ReadFile(filepath.Join(root, cacheStatsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// ListCacheEntries returns the entries under root matching the filter, oldest first.
+func ListCacheEntries(root string, filter CacheFilter) ([]CacheEntryInfo, error) {
+	var entries []CacheEntryInfo
+	err := walkCache(root, func(p string, size int64) error {
+		info, err := ReadCacheEntry(p)
+		if err != nil {
+			return nil // Corrupt entries are reported and removed by VacuumCache
+		}
+		info.Size = size
+		if filter.matches(info) {
+			entries = append(entries, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StoredAt.Before(entries[j].StoredAt) })
+	return entries, nil
+}
+
+// ReadCacheEntry reads the cache entry stored in the file at p.
+func ReadCacheEntry(p string) (CacheEntryInfo, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return CacheEntryInfo{}, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntryInfo{}, err
+	}
+
+	kind, _ := entryKind(filepath.Base(p))
+	return CacheEntryInfo{
+		Path:          p,
+		Kind:          kind,
+		Provider:      entry.Provider,
+		Model:         entry.Model,
+		PromptVersion: entry.PromptVersion,
+		Namespace:     entry.Namespace,
+		Key:           displayKey(kind, entry.Key),
+		Negative:      entry.Negative,
+		StoredAt:      entry.StoredAt,
+		RunID:         entry.RunID,
+		Size:          int64(len(data)),
+		Concepts:      entry.Concepts,
+	}, nil
+}
+
+// SummarizeCache aggregates the entries under root.
+func SummarizeCache(root string) (CacheSummary, error) {
+	summary := CacheSummary{ByKind: make(map[string]int), ByPartition: make(map[string]int)}
+
+	entries, err := ListCacheEntries(root, CacheFilter{})
+	if err != nil {
+		return summary, err
+	}
+	for _, e := range entries {
+		summary.Entries++
+		summary.Size += e.Size
+		summary.ByKind[e.Kind]++
+		summary.ByPartition[path.Join(e.Provider, e.Model, e.PromptVersion, e.Namespace)]++
+		if e.Negative {
+			summary.Negative++
+		}
+		if summary.OldestStored.IsZero() || e.StoredAt.Before(summary.OldestStored) {
+			summary.OldestStored = e.StoredAt
+		}
+		if e.StoredAt.After(summary.NewestStored) {
+			summary.NewestStored = e.StoredAt
+		}
+	}
+
+	summary.Lookups, err = ReadCumulativeCacheStats(root)
+	return summary, err
+}
+
+// DeleteCacheEntries removes the entries under root matching the filter and returns how many were removed.
+func DeleteCacheEntries(root string, filter CacheFilter) (int, error) {
+	entries, err := ListCacheEntries(root, filter)
+	if err != nil {
+		return 0, err
+	}
+	for i, e := range entries {
+		if err := os.Remove(e.Path); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+// EvictCache removes the oldest entries under root until the rest take up at most maxSize bytes. A maxSize of 0
+// removes every entry.
+func EvictCache(root string, maxSize int64) (EvictResult, error) {
+	var result EvictResult
+	entries, err := ListCacheEntries(root, CacheFilter{})
+	if err != nil {
+		return result, err
+	}
+	for _, e := range entries {
+		result.RemainingBytes += e.Size
+	}
+	for _, e := range entries {
+		if result.RemainingBytes <= maxSize {
+			break
+		}
+		if err := os.Remove(e.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return result, err
+		}
+		result.Entries++
+		result.FreedBytes += e.Size
+		result.RemainingBytes -= e.Size
+	}
+	return result, nil
+}
+
+// RenameCachedRelations rewrites the relation of the cached answers for which rename returns a new relation, so
+// answers cached before a relation type was renamed stay valid. It returns the number of entries rewritten.
+func RenameCachedRelations(root string, rename func(relation string) (string, bool)) (int, error) {
+	rewritten := 0
+	err := walkCache(root, func(p string, size int64) error {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil // Corrupt entries are reported and removed by VacuumCache
+		}
+
+		changed := false
+		for i, c := range entry.Concepts {
+			if relation, ok := rename(c.Relation); ok {
+				entry.Concepts[i].Relation = relation
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		if err := writeEntry(filepath.Dir(p), p, entry); err != nil {
+			return err
+		}
+		rewritten++
+		return nil
+	})
+	return rewritten, err
+}
+
+// VacuumCache removes expired and corrupt entries, leftover temporary files and empty partition directories.
+func VacuumCache(root string, ttl, negativeTTL time.Duration) (VacuumResult, error) {
+	var result VacuumResult
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".tmp-") {
+			result.TempFiles++
+			result.FreedBytes += info.Size()
+			return os.Remove(p)
+		}
+		if _, ok := entryKind(d.Name()); !ok {
+			return nil
+		}
+
+		entry, err := ReadCacheEntry(p)
+		if err != nil {
+			result.Corrupt++
+			result.FreedBytes += info.Size()
+			return os.Remove(p)
+		}
+		limit := ttl
+		if entry.Negative {
+			limit = negativeTTL
+		}
+		if time.Since(entry.StoredAt) > limit {
+			result.Expired++
+			result.FreedBytes += info.Size()
+			return os.Remove(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.EmptyDirs, err = removeEmptyDirs(root)
+	return result, err
+}
+
+// walkCache calls fn for every cache entry file under root.
+func walkCache(root string, fn func(p string, size int64) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if _, ok := entryKind(d.Name()); !ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(p, info.Size())
+	})
+}
+
+// removeEmptyDirs removes empty directories below root, deepest first, and returns how many were removed.
+func removeEmptyDirs(root string) (int, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != root {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i := len(dirs) - 1; i >= 0; i-- {
+		children, err := os.ReadDir(dirs[i])
+		if err != nil {
+			return removed, err
+		}
+		if len(children) == 0 {
+			if err := os.Remove(dirs[i]); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (f CacheFilter) matches(e CacheEntryInfo) bool {
+	if f.Kind != "" && f.Kind != e.Kind {
+		return false
+	}
+	if f.Model != "" && f.Model != e.Model {
+		return false
+	}
+	if f.Namespace != "" && f.Namespace != e.Namespace {
+		return false
+	}
+	if f.Negative && !e.Negative {
+		return false
+	}
+	if f.Pattern != "" {
+		matched, err := path.Match(f.Pattern, e.Key)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// displayKey turns an internal cache key into a readable one.
+func displayKey(kind, key string) string {
+	if kind == cacheKindRelationship {
+		return strings.Replace(key, "\x00", " -> ", 1)
+	}
+	if concept, family, ok := strings.Cut(key, "\x00"); ok {
+		return concept + " [" + family + "]" // Answer to a relation family prompt
+	}
+	return key
+}
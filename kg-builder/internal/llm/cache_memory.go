@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"kg-builder/internal/models"
+)
+
+// memoryStore holds the answers cached in memory by the partitions of a client. Once they take up more than
+// maxSize bytes, the oldest are evicted down to 90% of it.
+type memoryStore struct {
+	maxSize int64 // 0 is unlimited
+	mutex   sync.Mutex
+	entries map[string]memoryEntry
+	size    int64
+}
+
+type memoryEntry struct {
+	entry cacheEntry
+	size  int64 // Size of the entry encoded as JSON, as the file cache would store it
+}
+
+func newMemoryStore(maxSize int64) *memoryStore {
+	return &memoryStore{maxSize: maxSize, entries: make(map[string]memoryEntry)}
+}
+
+func (ms *memoryStore) get(key string) (cacheEntry, bool) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	e, ok := ms.entries[key]
+	e.entry.Concepts = append([]models.Concept(nil), e.entry.Concepts...) // Callers may modify the answer
+	return e.entry, ok
+}
+
+func (ms *memoryStore) put(key string, entry cacheEntry, size int64) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	entry.Concepts = append([]models.Concept(nil), entry.Concepts...)
+	ms.size += size - ms.entries[key].size
+	ms.entries[key] = memoryEntry{entry: entry, size: size}
+	if ms.maxSize > 0 && ms.size > ms.maxSize {
+		ms.evict(ms.maxSize * 9 / 10)
+	}
+}
+
+func (ms *memoryStore) remove(key string) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.size -= ms.entries[key].size
+	delete(ms.entries, key)
+}
+
+// evict removes the oldest entries until the rest take up at most maxSize bytes. The caller must hold the mutex.
+func (ms *memoryStore) evict(maxSize int64) {
+	keys := make([]string, 0, len(ms.entries))
+	for k := range ms.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return ms.entries[keys[i]].entry.StoredAt.Before(ms.entries[keys[j]].entry.StoredAt)
+	})
+	evicted := 0
+	for _, k := range keys {
+		if ms.size <= maxSize {
+			break
+		}
+		ms.size -= ms.entries[k].size
+		delete(ms.entries, k)
+		evicted++
+	}
+	logger.Info("Evicted the oldest cache entries", "entries", evicted)
+}
+
+// memoryCache keeps the answers of one partition in memory for the duration of the run, with the same TTLs as the
+// file cache. It suits runs that must neither reuse nor leave cached answers.
+type memoryCache struct {
+	cacheCounter
+	store       *memoryStore
+	partition   cachePartition
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+func newMemoryCache(store *memoryStore, partition cachePartition, ttl, negativeTTL time.Duration) *memoryCache {
+	return &memoryCache{store: store, partition: partition, ttl: ttl, negativeTTL: negativeTTL}
+}
+
+func (mc *memoryCache) get(kind, key string) (*cacheEntry, bool) {
+	entry, ok := mc.store.get(mc.key(kind, key))
+	if !ok {
+		mc.record(func(s *CacheStats) { s.Misses++ })
+		return nil, false
+	}
+	if entry.expired(mc.ttl, mc.negativeTTL) {
+		mc.store.remove(mc.key(kind, key))
+		mc.record(func(s *CacheStats) { s.Misses++; s.Expired++ })
+		return nil, false
+	}
+	mc.recordHit(&entry)
+	return &entry, true
+}
+
+func (mc *memoryCache) put(kind string, entry cacheEntry) error {
+	entry.cachePartition = mc.partition
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	mc.store.put(mc.key(kind, entry.Key), entry, int64(len(data)))
+	return nil
+}
+
+// flushStats does nothing: the memory cache leaves nothing behind, its statistics included.
+func (mc *memoryCache) flushStats() error {
+	return nil
+}
+
+func (mc *memoryCache) key(kind, key string) string {
+	return mc.partition.dir() + "/" + kind + "\x00" + key
+}
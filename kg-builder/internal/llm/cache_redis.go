@@ -0,0 +1,234 @@
+package llm
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisKeyPrefix namespaces the keys of the cache in a shared Redis server.
+const redisKeyPrefix = "kg-builder:cache:"
+
+// redisCache stores the answers of one partition in Redis, so builders running in several containers share them.
+// Entries are written with their TTL and expire in Redis; its maxmemory policy bounds the size of the cache.
+type redisCache struct {
+	cacheCounter
+	client      *redisClient
+	partition   cachePartition
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+func newRedisCache(client *redisClient, partition cachePartition, ttl, negativeTTL time.Duration) *redisCache {
+	return &redisCache{client: client, partition: partition, ttl: ttl, negativeTTL: negativeTTL}
+}
+
+func (rc *redisCache) get(kind, key string) (*cacheEntry, bool) {
+	reply, err := rc.client.do("GET", rc.key(kind, key))
+	data, ok := reply.([]byte)
+	if err != nil || !ok {
+		if err != nil {
+			logger.Warn("Failed to read from the Redis cache", "key", key, "error", err)
+		}
+		rc.record(func(s *CacheStats) { s.Misses++ })
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key || entry.cachePartition != rc.partition {
+		rc.record(func(s *CacheStats) { s.Misses++ })
+		return nil, false
+	}
+	if entry.expired(rc.ttl, rc.negativeTTL) { // The TTL may have been shortened since the entry was written
+		rc.record(func(s *CacheStats) { s.Misses++; s.Expired++ })
+		return nil, false
+	}
+	rc.recordHit(&entry)
+	return &entry, true
+}
+
+func (rc *redisCache) put(kind string, entry cacheEntry) error {
+	entry.cachePartition = rc.partition
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	ttl := rc.ttl
+	if entry.Negative {
+		ttl = rc.negativeTTL
+	}
+	_, err = rc.client.do("SET", rc.key(kind, entry.Key), string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// flushStats does nothing: the statistics of runs sharing a Redis server are only logged by each run.
+func (rc *redisCache) flushStats() error {
+	return nil
+}
+
+// key returns the Redis key of the entry, e.g. kg-builder:cache:ollama/llama3.1_latest/v2/default:rel:<sha256>.
+func (rc *redisCache) key(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return redisKeyPrefix + filepath.ToSlash(rc.partition.dir()) + ":" + kind + ":" + hex.EncodeToString(sum[:])
+}
+
+// redisClient sends commands to a Redis server over a small pool of connections. It implements the few commands
+// the cache needs, with RESP, the Redis protocol.
+type redisClient struct {
+	addr     string
+	username string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// newRedisClient connects to the server at rawURL, redis://[[user]:password@]host:port[/db], and checks that it
+// answers.
+func newRedisClient(rawURL string) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	c := &redisClient{addr: u.Host, timeout: 5 * time.Second, idle: make(chan *redisConn, 16)}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+
+	if _, err := c.do("PING"); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", c.addr, err)
+	}
+	return c, nil
+}
+
+// do sends a command and returns its reply: a string, an int64, a []byte or nil. A command failing on an idle
+// connection, which the server may have closed, is retried once on a new one.
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	conn, pooled, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.timeout, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.conn.Close() // The connection may hold part of a reply
+		if pooled {
+			return c.do(args...)
+		}
+		return nil, err
+	}
+	select {
+	case c.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection, or a new one authenticated and switched to the database. The second result
+// tells which.
+func (c *redisClient) conn() (*redisConn, bool, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, true, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, false, err
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+	var setup [][]string
+	switch {
+	case c.password != "" && c.username != "":
+		setup = append(setup, []string{"AUTH", c.username, c.password})
+	case c.password != "":
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		if _, err := conn.do(c.timeout, args...); err != nil {
+			netConn.Close()
+			return nil, false, err
+		}
+	}
+	return conn, false, nil
+}
+
+func (rc *redisConn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	rc.conn.SetDeadline(time.Now().Add(timeout))
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(rc.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return rc.readReply()
+}
+
+func (rc *redisConn) readReply() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // A length of -1 is a nil reply
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
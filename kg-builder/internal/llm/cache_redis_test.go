@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeConn is a net.Conn reading canned replies and recording what is written to it.
+type fakeConn struct {
+	net.Conn
+	replies io.Reader
+	written bytes.Buffer
+	closed  bool
+}
+
+func (f *fakeConn) Read(p []byte) (int, error)    { return f.replies.Read(p) }
+func (f *fakeConn) Write(p []byte) (int, error)   { return f.written.Write(p) }
+func (f *fakeConn) SetDeadline(t time.Time) error { return nil }
+func (f *fakeConn) Close() error                  { f.closed = true; return nil }
+
+func newFakeRedisConn(replies string) (*redisConn, *fakeConn) {
+	fc := &fakeConn{replies: strings.NewReader(replies)}
+	return &redisConn{conn: fc, reader: bufio.NewReader(fc)}, fc
+}
+
+func TestRedisConnReplies(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies string
+		want    interface{}
+		wantErr string
+	}{
+		{"simple string", "+OK\r\n", "OK", ""},
+		{"integer", ":42\r\n", int64(42), ""},
+		{"bulk string", "$5\r\nhello\r\n", []byte("hello"), ""},
+		{"bulk string with CRLF", "$7\r\nab\r\ncd\r\n\r\n", []byte("ab\r\ncd\r"), ""},
+		{"empty bulk string", "$0\r\n\r\n", []byte{}, ""},
+		{"nil bulk string", "$-1\r\n", nil, ""},
+		{"error", "-ERR invalid expire time in 'set' command\r\n", nil, "redis: ERR invalid expire time in 'set' command"},
+		{"unexpected type", "*1\r\n", nil, `redis: unexpected reply "*1"`},
+		{"empty line", "\r\n", nil, "redis: empty reply"},
+		{"truncated bulk string", "$5\r\nhel", nil, "unexpected EOF"},
+		{"closed connection", "", nil, "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, _ := newFakeRedisConn(tt.replies)
+			got, err := conn.readReply()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("readReply() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readReply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readReply() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisConnEncodesCommands(t *testing.T) {
+	conn, fc := newFakeRedisConn("+OK\r\n")
+	if _, err := conn.do(time.Second, "SET", "key", "a\r\nb", "PX", "1000"); err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	want := "*5\r\n$3\r\nSET\r\n$3\r\nkey\r\n$4\r\na\r\nb\r\n$2\r\nPX\r\n$4\r\n1000\r\n"
+	if got := fc.written.String(); got != want {
+		t.Errorf("do() wrote %q, want %q", got, want)
+	}
+}
+
+func TestRedisClientKeepsConnectionAfterErrorReply(t *testing.T) {
+	conn, fc := newFakeRedisConn("-ERR wrong type\r\n")
+	c := &redisClient{timeout: time.Second, idle: make(chan *redisConn, 1)}
+	c.idle <- conn
+
+	_, err := c.do("GET", "key")
+	var replyErr redisError
+	if !errors.As(err, &replyErr) {
+		t.Fatalf("do() error = %v, want a redisError", err)
+	}
+	if fc.closed || len(c.idle) != 1 {
+		t.Error("the connection was not returned to the pool after an error reply")
+	}
+}
+
+func TestRedisClientRetriesClosedIdleConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for i := 0; i < 3; i++ { // *1, $4 and PING
+			reader.ReadString('\n')
+		}
+		io.WriteString(conn, "+PONG\r\n")
+	}()
+
+	stale, fc := newFakeRedisConn("") // The server closed the idle connection
+	c := &redisClient{addr: listener.Addr().String(), timeout: time.Second, idle: make(chan *redisConn, 1)}
+	c.idle <- stale
+
+	reply, err := c.do("PING")
+	if err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	if reply != "PONG" {
+		t.Errorf("do() = %#v, want PONG", reply)
+	}
+	if !fc.closed {
+		t.Error("the stale connection was not closed")
+	}
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// embedBatchSize is the number of texts sent in one embed request.
+const embedBatchSize = 100
+
+// Embed returns the embedding of each text, computed by the embedding model. The texts are sent in batches, each
+// tried on the endpoints in turn until one answers.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		batch := texts[start:min(start+embedBatchSize, len(texts))]
+		requestBody, err := json.Marshal(map[string]interface{}{"model": c.modelFor(taskEmbedding), "input": batch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		var vectors [][]float64
+		tried := make(map[*endpoint]bool)
+		for {
+			c.limiter.acquire()
+			e := c.endpoints.acquire(tried)
+			if e == nil {
+				c.limiter.release()
+				return nil, err // Every endpoint failed; err is the last failure
+			}
+			tried[e] = true
+			var retry bool
+			vectors, retry, err = c.embedAt(ctx, e.url, requestBody)
+			c.endpoints.release(e, retry)
+			c.limiter.release()
+			if !retry {
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(vectors))
+		}
+		embeddings = append(embeddings, vectors...)
+	}
+	return embeddings, nil
+}
+
+// embedAt sends an embed request to one endpoint. retry reports whether the endpoint failed rather than the request.
+func (c *Client) embedAt(ctx context.Context, baseURL string, requestBody []byte) (vectors [][]float64, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/embed", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("failed to make request to %s: %w", baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode >= 500, fmt.Errorf("unexpected status code from %s: %d", baseURL, resp.StatusCode)
+	}
+	var response struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, true, fmt.Errorf("error reading response: %w", err)
+	}
+	return response.Embeddings, false, nil
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"kg-builder/internal/models"
+)
+
+// ExampleSet is a curated set of few-shot examples injected into the prompts.
+type ExampleSet struct {
+	Expansions    []ExpansionExample `json:"expansions"`    // Good concept expansions
+	Relationships []models.Concept   `json:"relationships"` // Good relationships between two concepts
+	Unrelated     [][2]string        `json:"unrelated"`     // Pairs of concepts rightly answered with no relationship
+}
+
+// ExpansionExample is a concept with a good answer to the related-concepts prompt.
+type ExpansionExample struct {
+	Concept string           `json:"concept"`
+	Related []models.Concept `json:"related"`
+}
+
+// LoadExampleLibrary reads an examples file: a JSON object mapping domain names to example sets.
+func LoadExampleLibrary(path string) (map[string]ExampleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read examples file: %w", err)
+	}
+	var library map[string]ExampleSet
+	if err := json.Unmarshal(data, &library); err != nil {
+		return nil, fmt.Errorf("failed to parse examples file %s: %w", path, err)
+	}
+	return library, nil
+}
+
+// loadExamples returns the example set of the domain from the examples file, with a fingerprint identifying it for
+// the cache. Without an examples file there are no examples and the fingerprint is empty.
+func loadExamples(path, domain string) (*ExampleSet, string, error) {
+	if path == "" {
+		return nil, "", nil
+	}
+	library, err := LoadExampleLibrary(path)
+	if err != nil {
+		return nil, "", err
+	}
+	set, ok := library[domain]
+	if !ok {
+		domains := make([]string, 0, len(library))
+		for d := range library {
+			domains = append(domains, d)
+		}
+		sort.Strings(domains)
+		return nil, "", fmt.Errorf("examples file %s has no domain %q (available: %s)", path, domain, strings.Join(domains, ", "))
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	return &set, hex.EncodeToString(sum[:4]), nil
+}
+
+// ExpansionBlock returns the prompt text presenting the expansion examples, or "" if there are none.
+func (e *ExampleSet) ExpansionBlock() string {
+	if e == nil || len(e.Expansions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\tExamples of good answers:\n")
+	for _, ex := range e.Expansions {
+		related, _ := json.Marshal(ex.Related)
+		fmt.Fprintf(&b, "\tFor '%s': %s\n", ex.Concept, related)
+	}
+	return b.String()
+}
+
+// RelationshipBlock returns the prompt text presenting the relationship examples, or "" if there are none.
+func (e *ExampleSet) RelationshipBlock() string {
+	if e == nil || (len(e.Relationships) == 0 && len(e.Unrelated) == 0) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\tExamples of good answers:\n")
+	for _, r := range e.Relationships {
+		answer, _ := json.Marshal(r)
+		fmt.Fprintf(&b, "\tFor '%s' and '%s': %s\n", r.RelatedTo, r.Name, answer)
+	}
+	for _, pair := range e.Unrelated {
+		fmt.Fprintf(&b, "\tFor '%s' and '%s': {\"name\": \"\", \"relation\": \"\", \"relatedTo\": \"\"}\n", pair[0], pair[1])
+	}
+	return b.String()
+}
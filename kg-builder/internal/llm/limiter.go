@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRetryAfter caps the wait a throttling server can ask for, so a bogus Retry-After does not stall the build.
+const maxRetryAfter = 5 * time.Minute
+
+// limiter paces the generate requests sent to all endpoints: at most a number of requests in flight, at most a
+// number of requests per minute, and none while a server that throttled a request asked to wait.
+type limiter struct {
+	slots       chan struct{} // One per request in flight; nil if unlimited
+	interval    time.Duration // Time between two request starts; 0 if unlimited
+	mutex       sync.Mutex
+	next        time.Time // Earliest start of the next request
+	pausedUntil time.Time
+}
+
+func newLimiter(perMinute, maxInFlight int) *limiter {
+	l := &limiter{}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	if perMinute > 0 {
+		l.interval = time.Minute / time.Duration(perMinute)
+	}
+	return l
+}
+
+// acquire blocks until a request may start. Every acquire must be followed by a release.
+func (l *limiter) acquire() {
+	if l.slots != nil {
+		l.slots <- struct{}{}
+	}
+
+	// Reserve the next start time, so waiting requests start in turn and evenly spaced
+	l.mutex.Lock()
+	start := time.Now()
+	if start.Before(l.pausedUntil) {
+		start = l.pausedUntil
+	}
+	if l.interval > 0 {
+		if start.Before(l.next) {
+			start = l.next
+		}
+		l.next = start.Add(l.interval)
+	}
+	l.mutex.Unlock()
+	time.Sleep(time.Until(start))
+}
+
+// release ends a request started by acquire.
+func (l *limiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// pause holds back the requests not started yet for d.
+func (l *limiter) pause(d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// throttledError is returned for a request the server refused because too many were sent.
+type throttledError struct {
+	url        string
+	status     int
+	retryAfter time.Duration // Wait the server asked for; 0 if it did not say
+}
+
+func (e *throttledError) Error() string {
+	return fmt.Sprintf("throttled by %s: status %d", e.url, e.status)
+}
+
+// throttled returns the error for a response throttling the request, or nil if it does not. A 503 only counts as
+// throttling when it has a Retry-After header; otherwise the endpoint is taken to be failing.
+func throttled(url string, resp *http.Response) *throttledError {
+	retryAfter, hasHeader := parseRetryAfter(resp.Header.Get("Retry-After"))
+	if resp.StatusCode != http.StatusTooManyRequests && !(resp.StatusCode == http.StatusServiceUnavailable && hasHeader) {
+		return nil
+	}
+	return &throttledError{url: url, status: resp.StatusCode, retryAfter: retryAfter}
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP date, capped at maxRetryAfter.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		d = time.Until(at)
+	} else {
+		return 0, false
+	}
+	return min(max(d, 0), maxRetryAfter), true
+}
@@ -4,87 +4,357 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"kg-builder/internal/breaker"
+	"kg-builder/internal/config"
+	"kg-builder/internal/logging"
 	"kg-builder/internal/models"
+
+	"golang.org/x/sync/singleflight"
 )
 
-// GetRelatedConcepts sends a request to the LLM service to get related concepts for a given concept.
-
-func GetRelatedConcepts(concept string) ([]models.Concept, error) {
-	url := "http://host.docker.internal:11434/api/generate"
-	prompt := fmt.Sprintf(`You are an expert ontologist with an understanding of concepts and the relationships between them. You respond only in JSON. 
-	Given the concept '%s', provide 5 related concepts. 
-	For each, specify the relationship type. 
-	Return ONLY a JSON array with 'name', 'relation', and 'relatedTo' keys. 
-	Do not include any explanations, markdown formatting, or additional text. 
-	The response should be valid JSON that can be directly parsed. Example format:
-    [
-        {
-            "name": "Related Concept 1",
-            "relation": "RelationType",
-            "relatedTo": "%s"
-        },
-        ...
-    ]
-	Do not return any explanations, markdown formatting, or additional text.
-	`, concept, concept)
+// logger is the logger of the llm module.
+var logger = logging.For("llm")
 
-	// Marshal the request body
-	requestBody, err := json.Marshal(map[string]string{
-		"model":  "llama3.1:latest", // TODO: Make this configurable
-		"prompt": prompt,            // TODO: Make this configurable
-	})
+// Task types, each routed to its own model (see config.LLMConfig).
+const (
+	taskExpansion = "expansion"
+	taskMining    = "mining"
+	taskCuration  = "curation"
+	taskEmbedding = "embedding"
+)
+
+// cacheTasks maps each cache entry kind to the task producing it.
+var cacheTasks = map[string]string{
+	cacheKindRelated:      taskExpansion,
+	cacheKindRelationship: taskMining,
+}
+
+// Client talks to the Ollama API using the configured endpoint and model.
+type Client struct {
+	config     config.LLMConfig
+	httpClient *http.Client
+	inflight   singleflight.Group      // Deduplicates identical requests made concurrently by different workers
+	caches     map[string]cacheBackend // Cache partition of each model answering cached tasks; nil when caching is disabled
+	families   []relationFamily        // Relation families to expand concepts with; empty means one generic prompt
+	examples   *ExampleSet             // Few-shot examples added to the prompts; nil when there are none
+	endpoints  *balancer               // Ollama servers the generate requests are spread over
+	limiter    *limiter                // Paces the generate requests over all servers
+	breaker    *breaker.Breaker        // Refuses generate requests while the servers keep failing; nil if disabled
+}
+
+// NewClient creates a new Client for the given configuration.
+func NewClient(cfg config.LLMConfig) (*Client, error) {
+	c := &Client{
+		config:     cfg,
+		httpClient: &http.Client{},
+		endpoints:  newBalancer(cfg.URLs, cfg.Balancing, cfg.Cooldown),
+		limiter:    newLimiter(cfg.RateLimit, cfg.MaxInFlight),
+		breaker:    breaker.New("llm", cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
 
-	// Check if the request body was marshalled successfully
+	families, fingerprint, err := loadRelationFamilies(cfg.RelationFamilies, cfg.PromptsFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
+	c.families = families
 
-	// Send the request to the LLM service
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	examples, examplesFingerprint, err := loadExamples(cfg.ExamplesFile, cfg.ExamplesDomain)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	c.examples = examples
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if cfg.CacheEnabled {
+		version := promptVersion
+		if cfg.RelatedCount != defaultRelatedCount {
+			version += fmt.Sprintf("-n%d", cfg.RelatedCount) // Answers to a different count are not interchangeable
+		}
+		if fingerprint != "" {
+			version += "-" + fingerprint // Custom prompts get their own partition
+		}
+		if examplesFingerprint != "" {
+			version += "-x" + examplesFingerprint // So do prompts with examples
+		}
+		c.caches = make(map[string]cacheBackend)
+		var budget *cacheBudget
+		var store *memoryStore
+		var redis *redisClient
+		switch {
+		case cfg.CacheBackend == "memory":
+			store = newMemoryStore(cfg.CacheMaxSize)
+		case cfg.CacheBackend == "redis":
+			if redis, err = newRedisClient(cfg.CacheRedisURL); err != nil {
+				return nil, err
+			}
+		case cfg.CacheMaxSize > 0:
+			if budget, err = newCacheBudget(cfg.CacheDir, cfg.CacheMaxSize); err != nil {
+				return nil, err
+			}
+		}
+		for _, model := range c.buildModels() {
+			partition := cachePartition{
+				Provider:      "ollama",
+				Model:         model,
+				PromptVersion: version,
+				Namespace:     cfg.CacheNamespace,
+			}
+			if store != nil {
+				c.caches[model] = newMemoryCache(store, partition, cfg.CacheTTL, cfg.CacheNegativeTTL)
+				continue
+			}
+			if redis != nil {
+				c.caches[model] = newRedisCache(redis, partition, cfg.CacheTTL, cfg.CacheNegativeTTL)
+				continue
+			}
+			cache, err := newFileCache(cfg.CacheDir, partition, cfg.CacheTTL, cfg.CacheNegativeTTL, budget)
+			if err != nil {
+				return nil, err
+			}
+			c.caches[model] = cache
+		}
 	}
 
-	// Read the response from the LLM service
-	var fullResponse strings.Builder
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-		var streamResponse struct {
-			Response string `json:"response"`
+	return c, nil
+}
+
+// Close persists the cache statistics of this run.
+func (c *Client) Close() error {
+	for _, cache := range c.caches {
+		if err := cache.flushStats(); err != nil {
+			return err
 		}
-		if err := json.Unmarshal([]byte(line), &streamResponse); err == nil {
-			fullResponse.WriteString(streamResponse.Response)
+	}
+	return nil
+}
+
+// CacheStats returns the cache statistics collected so far.
+func (c *Client) CacheStats() CacheStats {
+	var stats CacheStats
+	for _, cache := range c.caches {
+		s := cache.snapshot()
+		stats.Hits += s.Hits
+		stats.NegativeHits += s.NegativeHits
+		stats.Misses += s.Misses
+		stats.Expired += s.Expired
+	}
+	return stats
+}
+
+// modelFor returns the model the given task is routed to.
+func (c *Client) modelFor(task string) string {
+	switch task {
+	case taskExpansion:
+		return c.config.ExpansionModel
+	case taskMining:
+		return c.config.MiningModel
+	case taskCuration:
+		return c.config.CurationModel
+	case taskEmbedding:
+		return c.config.EmbeddingModel
+	}
+	return c.config.Model
+}
+
+// buildModels returns the distinct models used while building the graph.
+func (c *Client) buildModels() []string {
+	models := []string{c.modelFor(taskExpansion)}
+	if mining := c.modelFor(taskMining); mining != models[0] {
+		models = append(models, mining)
+	}
+	return models
+}
+
+// GetRelatedConcepts returns related concepts for a given concept. Concurrent requests for the same concept share a single LLM call.
+func (c *Client) GetRelatedConcepts(concept string) ([]models.Concept, error) {
+	val, err, shared := c.inflight.Do("related:"+concept, func() (interface{}, error) {
+		return c.expandConcept(concept)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		logger.Debug("Shared in-flight LLM result for related concepts", "concept", concept)
+	}
+
+	// Give each caller its own copy so workers cannot modify each other's results
+	concepts := val.([]models.Concept)
+	return append([]models.Concept(nil), concepts...), nil
+}
+
+// expandConcept asks for related concepts with the generic prompt or, when relation families are configured,
+// with one targeted prompt per family, and merges the answers.
+func (c *Client) expandConcept(concept string) ([]models.Concept, error) {
+	if len(c.families) == 0 {
+		return c.cachedRelatedConcepts(concept, relatedConceptsPrompt(concept, c.config.RelatedCount)+c.examples.ExpansionBlock())
+	}
+
+	var merged []models.Concept
+	seen := map[string]bool{strings.ToLower(concept): true}
+	failures := 0
+	var lastErr error
+	for _, family := range c.families {
+		concepts, err := c.cachedRelatedConcepts(concept+"\x00"+family.Name, family.prompt(concept, c.config.RelatedCount)+c.examples.ExpansionBlock())
+		if errors.Is(err, breaker.ErrOpen) {
+			// The breaker opened partway through: defer the whole concept rather than keep a partial expansion. The
+			// families already answered are cached, so expanding it again is cheap.
+			return nil, err
+		}
+		if err != nil {
+			logger.Warn("Error getting related concepts of a family", "family", family.Name, "concept", concept, "error", err)
+			failures++
+			lastErr = err
+			continue
+		}
+		for _, rc := range concepts {
+			name := strings.ToLower(strings.TrimSpace(rc.Name))
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			merged = append(merged, rc)
 		}
 	}
 
-	// Check if there was an error reading the response
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+	if failures == len(c.families) {
+		return nil, lastErr
+	}
+	return merged, nil
+}
+
+// cachedRelatedConcepts answers a related-concepts prompt from the cache, or asks the LLM and caches the answer.
+func (c *Client) cachedRelatedConcepts(key, prompt string) ([]models.Concept, error) {
+	if entry, ok := c.cacheGet(cacheKindRelated, key); ok {
+		return entry.Concepts, nil
+	}
+	concepts, err := c.getRelatedConcepts(prompt)
+	if err != nil {
+		return nil, err
+	}
+	c.cachePut(cacheKindRelated, key, concepts)
+	return concepts, nil
+}
+
+// MineRelationship determines if there is a relationship between two concepts. Concurrent requests for the same pair share a single LLM call.
+func (c *Client) MineRelationship(concept1, concept2 string) (*models.Concept, error) {
+	key := concept1 + "\x00" + concept2
+	val, err, shared := c.inflight.Do("relationship:"+key, func() (interface{}, error) {
+		if entry, ok := c.cacheGet(cacheKindRelationship, key); ok {
+			if entry.Negative || len(entry.Concepts) == 0 {
+				return (*models.Concept)(nil), nil
+			}
+			return &entry.Concepts[0], nil
+		}
+		concept, err := c.mineRelationship(concept1, concept2)
+		if err != nil {
+			return nil, err
+		}
+		if concept == nil {
+			c.cachePut(cacheKindRelationship, key, nil) // Remember that the pair is unrelated
+		} else {
+			c.cachePut(cacheKindRelationship, key, []models.Concept{*concept})
+		}
+		return concept, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		logger.Debug("Shared in-flight LLM result for relationship", "from", concept1, "to", concept2)
+	}
+
+	concept := val.(*models.Concept)
+	if concept == nil {
+		return nil, nil // No relationship found
+	}
+	result := *concept
+	return &result, nil
+}
+
+// SuggestSplit asks the LLM which of the targets an over-broad concept is split into each of its neighbors belongs
+// to. Neighbors the LLM leaves out or assigns to an unknown target are missing from the result.
+func (c *Client) SuggestSplit(concept string, targets, neighbors []string) (map[string]string, error) {
+	if len(targets) == 0 || len(neighbors) == 0 {
+		return map[string]string{}, nil
+	}
+
+	response, err := c.generate(taskCuration, splitAssignmentPrompt(concept, targets, neighbors))
+	if err != nil {
+		return nil, err
+	}
+
+	var answer map[string]string
+	if err := json.Unmarshal([]byte(response), &answer); err != nil {
+		logger.Debug("Raw LLM response", "response", response)
+		return nil, fmt.Errorf("failed to unmarshal split assignment: %w", err)
+	}
+
+	valid := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		valid[t] = true
+	}
+	assignments := make(map[string]string)
+	for _, n := range neighbors {
+		if target, ok := answer[n]; ok && valid[target] {
+			assignments[n] = target
+		}
+	}
+	return assignments, nil
+}
+
+// cacheGet looks the key up in the cache, if caching is enabled.
+func (c *Client) cacheGet(kind, key string) (*cacheEntry, bool) {
+	cache := c.caches[c.modelFor(cacheTasks[kind])]
+	if cache == nil {
+		return nil, false
+	}
+	return cache.get(kind, key)
+}
+
+// cachePut stores an answer in the cache, if caching is enabled. An empty answer to a mined relationship ("no
+// relationship") is stored as a negative entry; an empty expansion is stored as an ordinary one.
+func (c *Client) cachePut(kind, key string, concepts []models.Concept) {
+	cache := c.caches[c.modelFor(cacheTasks[kind])]
+	if cache == nil {
+		return
+	}
+	entry := cacheEntry{
+		Key:      key,
+		Negative: kind == cacheKindRelationship && len(concepts) == 0,
+		StoredAt: time.Now(),
+		RunID:    logging.RunID(),
+		Concepts: concepts,
+	}
+	if err := cache.put(kind, entry); err != nil {
+		logger.Warn("Failed to cache LLM answer", "key", key, "error", err)
+	}
+}
+
+// getRelatedConcepts sends a related-concepts prompt to the LLM service and parses the returned concepts.
+func (c *Client) getRelatedConcepts(prompt string) ([]models.Concept, error) {
+	response, err := c.generate(taskExpansion, prompt)
+	if err != nil {
+		return nil, err
 	}
 
 	// Unmarshal the response into a slice of Concept structs
 	var concepts []models.Concept
-	if err := json.Unmarshal([]byte(fullResponse.String()), &concepts); err != nil {
-		fmt.Printf("Raw LLM response: %s\n", fullResponse.String())
+	if err := json.Unmarshal([]byte(response), &concepts); err != nil {
+		logger.Debug("Raw LLM response", "response", response)
 		return nil, fmt.Errorf("failed to unmarshal concepts: %w", err)
 	}
 
 	return concepts, nil
 }
 
-// MineRelationship sends a request to the LLM service to determine if there is a relationship between two concepts.
-func MineRelationship(concept1, concept2 string) (*models.Concept, error) {
-	url := "http://host.docker.internal:11434/api/generate"
+// mineRelationship sends a request to the LLM service to determine if there is a relationship between two concepts.
+func (c *Client) mineRelationship(concept1, concept2 string) (*models.Concept, error) {
 	prompt := fmt.Sprintf(`You are an expert ontologist and respond only in JSON. 
 	Determine if there's a relationship between the concepts '%s' and '%s'. If there is, provide the relationship type. 
 	If not, respond with "No relationship". 
@@ -102,25 +372,105 @@ func MineRelationship(concept1, concept2 string) (*models.Concept, error) {
         "relatedTo": ""
     }
 	Do not return any explanations, markdown formatting, or additional text.`, concept1, concept2, concept2, concept1)
+	prompt += c.examples.RelationshipBlock()
 
-	requestBody, err := json.Marshal(map[string]string{
-		"model":  "llama3.1:latest",
-		"prompt": prompt,
-	})
+	response, err := c.generate(taskMining, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
+	}
+
+	// Unmarshal the response into a Concept struct
+	var concept models.Concept
+	if err := json.Unmarshal([]byte(response), &concept); err != nil {
+		logger.Debug("Raw LLM response", "response", response)
+		return nil, fmt.Errorf("failed to unmarshal concept: %w", err)
+	}
+
+	// Check if the relationship is empty
+	if concept.Relation == "" {
+		return nil, nil // No relationship found
 	}
 
+	return &concept, nil
+}
+
+// Breaker returns the circuit breaker of the generate requests, nil if it is disabled.
+func (c *Client) Breaker() *breaker.Breaker {
+	return c.breaker
+}
+
+// generate sends the prompt to the Ollama generate endpoint, using the model the task is routed to, and returns the
+// concatenated streamed response. It fails at once with an error wrapping breaker.ErrOpen while the circuit breaker
+// is open.
+func (c *Client) generate(task, prompt string) (string, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return "", err
+	}
+	response, err := c.generateWithRetries(task, prompt)
+	c.breaker.Record(err)
+	return response, err
+}
+
+// generateWithRetries sends a generate request. A request failing on one endpoint is retried on the others, and a
+// throttled request is retried once the wait the server asked for is over.
+func (c *Client) generateWithRetries(task, prompt string) (string, error) {
+	// Marshal the request body
+	requestBody, err := json.Marshal(c.generateRequest(c.modelFor(task), prompt))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	tried := make(map[*endpoint]bool)
+	throttles := 0
+	for {
+		c.limiter.acquire()
+		e := c.endpoints.acquire(tried)
+		if e == nil {
+			c.limiter.release()
+			return "", err // Every endpoint failed; err is the last failure
+		}
+		tried[e] = true
+		var response string
+		var retry bool
+		response, retry, err = c.generateAt(e.url, requestBody)
+		c.endpoints.release(e, retry)
+		c.limiter.release()
+
+		var throttle *throttledError
+		if errors.As(err, &throttle) && throttles < c.config.ThrottleRetries {
+			throttles++
+			wait := throttle.retryAfter
+			if wait == 0 {
+				wait = time.Second << (throttles - 1) // No Retry-After: back off exponentially
+			}
+			logger.Warn("LLM request throttled, waiting before retrying", "endpoint", e.url, "status", throttle.status,
+				"wait", wait, "attempt", throttles)
+			c.limiter.pause(wait)
+			delete(tried, e)
+			continue
+		}
+		if !retry {
+			return response, err
+		}
+	}
+}
+
+// generateAt sends a generate request to one endpoint. retry reports whether the endpoint failed rather than the
+// request, so another endpoint may succeed.
+func (c *Client) generateAt(baseURL string, requestBody []byte) (response string, retry bool, err error) {
 	// Send the request to the LLM service
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	resp, err := c.httpClient.Post(baseURL+"/api/generate", "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return "", true, fmt.Errorf("failed to make request to %s: %w", baseURL, err)
 	}
 	defer resp.Body.Close()
 
 	// Check if the response status code is OK
+	if err := throttled(baseURL, resp); err != nil {
+		return "", false, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return "", resp.StatusCode >= 500, fmt.Errorf("unexpected status code from %s: %d", baseURL, resp.StatusCode)
 	}
 
 	// Read the response from the LLM service
@@ -138,20 +488,35 @@ func MineRelationship(concept1, concept2 string) (*models.Concept, error) {
 
 	// Check if there was an error reading the response
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+		return "", true, fmt.Errorf("error reading response: %w", err)
 	}
 
-	// Unmarshal the response into a Concept struct
-	var concept models.Concept
-	if err := json.Unmarshal([]byte(fullResponse.String()), &concept); err != nil {
-		fmt.Printf("Raw LLM response: %s\n", fullResponse.String())
-		return nil, fmt.Errorf("failed to unmarshal concept: %w", err)
-	}
+	return fullResponse.String(), false, nil
+}
 
-	// Check if the relationship is empty
-	if concept.Relation == "" {
-		return nil, nil // No relationship found
+// generateRequest returns the body of a generate request, including the configured Ollama options.
+func (c *Client) generateRequest(model, prompt string) map[string]interface{} {
+	request := map[string]interface{}{
+		"model":  model,
+		"prompt": prompt,
+	}
+	if c.config.KeepAlive != "" {
+		if seconds, err := strconv.Atoi(c.config.KeepAlive); err == nil {
+			request["keep_alive"] = seconds // Ollama reads bare numbers as seconds, negative meaning for ever
+		} else {
+			request["keep_alive"] = c.config.KeepAlive
+		}
 	}
 
-	return &concept, nil
+	options := map[string]interface{}{}
+	if c.config.NumCtx > 0 {
+		options["num_ctx"] = c.config.NumCtx
+	}
+	if c.config.NumPredict > 0 {
+		options["num_predict"] = c.config.NumPredict
+	}
+	if len(options) > 0 {
+		request["options"] = options
+	}
+	return request
 }
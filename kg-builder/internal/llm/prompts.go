@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// relationFamily describes a family of relation types that gets its own targeted expansion prompt.
+type relationFamily struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"` // What kind of related concepts to look for
+	Relations   []string `json:"relations"`   // Example relation types for the family
+}
+
+// defaultRelationFamilies are the built-in families selectable through LLM_RELATION_FAMILIES.
+var defaultRelationFamilies = map[string]relationFamily{
+	"taxonomic": {
+		Name:        "taxonomic",
+		Description: "broader categories the concept belongs to, narrower subtypes of the concept and notable instances of it",
+		Relations:   []string{"IsA", "SubclassOf", "HasSubtype", "InstanceOf"},
+	},
+	"causal": {
+		Name:        "causal",
+		Description: "causes of the concept, effects it produces, things it enables and prerequisites it requires",
+		Relations:   []string{"Causes", "CausedBy", "Enables", "Requires"},
+	},
+	"temporal": {
+		Name:        "temporal",
+		Description: "concepts that came before or after it, that it evolved from or into, or that happen during it",
+		Relations:   []string{"Precedes", "Follows", "EvolvedFrom", "OccursDuring"},
+	},
+	"compositional": {
+		Name:        "compositional",
+		Description: "parts and components of the concept and the larger wholes or groups it is part of",
+		Relations:   []string{"PartOf", "HasPart", "ComposedOf", "MemberOf"},
+	},
+}
+
+// loadRelationFamilies resolves the configured family names against the built-in families, overridden or extended by
+// the optional JSON prompts file (an array of families). The returned fingerprint identifies custom prompts and is
+// empty when only built-in prompts are used.
+func loadRelationFamilies(names []string, promptsFile string) ([]relationFamily, string, error) {
+	available := make(map[string]relationFamily, len(defaultRelationFamilies))
+	for name, family := range defaultRelationFamilies {
+		available[name] = family
+	}
+
+	fingerprint := ""
+	if promptsFile != "" {
+		data, err := os.ReadFile(promptsFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read prompts file: %w", err)
+		}
+		var custom []relationFamily
+		if err := json.Unmarshal(data, &custom); err != nil {
+			return nil, "", fmt.Errorf("failed to parse prompts file %s: %w", promptsFile, err)
+		}
+		for _, family := range custom {
+			if family.Name == "" || family.Description == "" {
+				return nil, "", fmt.Errorf("prompts file %s: every family needs a name and a description", promptsFile)
+			}
+			available[family.Name] = family
+		}
+		sum := sha256.Sum256(data)
+		fingerprint = hex.EncodeToString(sum[:4])
+	}
+
+	families := make([]relationFamily, 0, len(names))
+	for _, name := range names {
+		family, ok := available[name]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown relation family %q (available: %s)", name, strings.Join(familyNames(available), ", "))
+		}
+		families = append(families, family)
+	}
+	return families, fingerprint, nil
+}
+
+func familyNames(families map[string]relationFamily) []string {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultRelatedCount is the number of related concepts the prompts of promptVersion were written for.
+const defaultRelatedCount = 5
+
+// diversityInstruction asks the LLM not to waste an expansion on near-duplicates.
+const diversityInstruction = `The concepts must be clearly distinct from each other and from '%s': do not return synonyms, abbreviations, spelling or plural variants of each other or of the given concept.`
+
+// relatedConceptsPrompt is the generic expansion prompt used when no relation families are configured.
+func relatedConceptsPrompt(concept string, count int) string {
+	return fmt.Sprintf(`You are an expert ontologist with an understanding of concepts and the relationships between them. You respond only in JSON. 
+	Given the concept '%s', provide %d related concepts. 
+	%s
+	For each, specify the relationship type. 
+	Return ONLY a JSON array with 'name', 'relation', and 'relatedTo' keys. 
+	Do not include any explanations, markdown formatting, or additional text. 
+	The response should be valid JSON that can be directly parsed. Example format:
+    [
+        {
+            "name": "Related Concept 1",
+            "relation": "RelationType",
+            "relatedTo": "%s"
+        },
+        ...
+    ]
+	Do not return any explanations, markdown formatting, or additional text.
+	`, concept, count, fmt.Sprintf(diversityInstruction, concept), concept)
+}
+
+// prompt returns the targeted expansion prompt for the family.
+func (f relationFamily) prompt(concept string, count int) string {
+	return fmt.Sprintf(`You are an expert ontologist with an understanding of concepts and the relationships between them. You respond only in JSON. 
+	Given the concept '%s', provide %d related concepts connected to it by %s relationships: %s. 
+	%s
+	For each, specify the relationship type, preferably one of: %s. 
+	Return ONLY a JSON array with 'name', 'relation', and 'relatedTo' keys. 
+	The response should be valid JSON that can be directly parsed. Example format:
+    [
+        {
+            "name": "Related Concept 1",
+            "relation": "%s",
+            "relatedTo": "%s"
+        },
+        ...
+    ]
+	Do not return any explanations, markdown formatting, or additional text.
+	`, concept, count, f.Name, f.Description, fmt.Sprintf(diversityInstruction, concept), strings.Join(f.Relations, ", "), f.exampleRelation(), concept)
+}
+
+func (f relationFamily) exampleRelation() string {
+	if len(f.Relations) == 0 {
+		return "RelationType"
+	}
+	return f.Relations[0]
+}
+
+// splitAssignmentPrompt asks which of the concepts an over-broad concept is split into each neighbor belongs to.
+func splitAssignmentPrompt(concept string, targets, neighbors []string) string {
+	quoted := make([]string, len(targets))
+	for i, t := range targets {
+		quoted[i] = fmt.Sprintf("'%s'", t)
+	}
+	return fmt.Sprintf(`You are an expert ontologist and respond only in JSON. 
+	The concept '%s' is ambiguous and is being split into these concepts: %s. 
+	For each of the following related concepts, decide which of the new concepts it is related to: %s. 
+	Return ONLY a JSON object mapping each related concept, spelled exactly as given, to one of the new concepts, spelled exactly as given. 
+	Example format:
+    {
+        "Related Concept 1": "%s"
+    }
+	Do not return any explanations, markdown formatting, or additional text.
+	`, concept, strings.Join(quoted, ", "), strings.Join(neighbors, "; "), targets[0])
+}
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EnsureModel checks that the models used to build the graph are available on every Ollama endpoint and, if
+// AutoPull is enabled, pulls the missing ones.
+func (c *Client) EnsureModel(ctx context.Context) error {
+	for _, baseURL := range c.endpoints.urls() {
+		for _, model := range c.buildModels() {
+			if err := c.ensureModel(ctx, baseURL, model); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MissingModels returns the configured models, including the curation model, that are not available on an Ollama
+// endpoint. With several endpoints each missing model is followed by the endpoint lacking it.
+func (c *Client) MissingModels(ctx context.Context) ([]string, error) {
+	models := c.buildModels()
+	if curation := c.modelFor(taskCuration); curation != models[0] && (len(models) == 1 || curation != models[1]) {
+		models = append(models, curation)
+	}
+
+	urls := c.endpoints.urls()
+	var missing []string
+	for _, baseURL := range urls {
+		for _, model := range models {
+			present, err := c.hasModel(ctx, baseURL, model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list models of %s: %w", baseURL, err)
+			}
+			switch {
+			case present:
+			case len(urls) > 1:
+				missing = append(missing, model+" ("+baseURL+")")
+			default:
+				missing = append(missing, model)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// ensureModel checks that the model is available on the endpoint and, if AutoPull is enabled, pulls it when missing.
+func (c *Client) ensureModel(ctx context.Context, baseURL, model string) error {
+	present, err := c.hasModel(ctx, baseURL, model)
+	if err != nil {
+		return fmt.Errorf("failed to list models of %s: %w", baseURL, err)
+	}
+	if present {
+		logger.Info("Model is available", "model", model, "endpoint", baseURL)
+		return nil
+	}
+
+	if !c.config.AutoPull {
+		return fmt.Errorf("model %s is not available on %s (set LLM_AUTO_PULL=true to pull it automatically)", model, baseURL)
+	}
+
+	logger.Info("Model not found, pulling it", "model", model, "endpoint", baseURL, "timeout", c.config.PullTimeout)
+	ctx, cancel := context.WithTimeout(ctx, c.config.PullTimeout)
+	defer cancel()
+	return c.pullModel(ctx, baseURL, model)
+}
+
+// hasModel reports whether the model is listed by the tags endpoint of the Ollama server.
+func (c *Client) hasModel(ctx context.Context, baseURL, model string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/tags", nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return false, fmt.Errorf("failed to decode tags: %w", err)
+	}
+
+	want := normalizeModelName(model)
+	for _, m := range tags.Models {
+		if normalizeModelName(m.Name) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pullModel asks the Ollama server to pull the model and logs the streamed progress.
+func (c *Client) pullModel(ctx context.Context, baseURL, model string) error {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"name":   model,
+		"stream": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/pull", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to pull model %s: %w", model, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code while pulling model %s: %d", model, resp.StatusCode)
+	}
+
+	lastStatus := ""
+	lastBucket := -1 // Last logged 10% step of the download
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var progress struct {
+			Status    string `json:"status"`
+			Error     string `json:"error"`
+			Total     int64  `json:"total"`
+			Completed int64  `json:"completed"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &progress); err != nil {
+			continue
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("failed to pull model %s: %s", model, progress.Error)
+		}
+
+		// Log status changes, and download progress in 10% steps to keep the log readable
+		if progress.Status != lastStatus {
+			logger.Info("Pulling model", "model", model, "status", progress.Status)
+			lastStatus = progress.Status
+			lastBucket = -1
+		}
+		if progress.Total > 0 {
+			percent := int(progress.Completed * 100 / progress.Total)
+			if percent/10 > lastBucket {
+				logger.Info("Pulling model", "model", model, "status", progress.Status, "percent", percent)
+				lastBucket = percent / 10
+			}
+		}
+		if progress.Status == "success" {
+			logger.Info("Model pulled successfully", "model", model)
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading pull progress: %w", err)
+	}
+	return fmt.Errorf("pull of model %s ended without success", model)
+}
+
+// normalizeModelName adds the implicit ":latest" tag so "llama3.1" and "llama3.1:latest" compare equal.
+func normalizeModelName(name string) string {
+	if !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
+
+// WarmUp loads the models used to build the graph on every endpoint with an empty generate request, so the first
+// expansion does not pay the model load time and run into timeouts.
+func (c *Client) WarmUp(ctx context.Context) error {
+	for _, baseURL := range c.endpoints.urls() {
+		if err := c.warmUp(ctx, baseURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warmUp loads the models used to build the graph on one endpoint.
+func (c *Client) warmUp(ctx context.Context, baseURL string) error {
+	for _, model := range c.buildModels() {
+		start := time.Now()
+		requestBody, err := json.Marshal(c.generateRequest(model, ""))
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/generate", bytes.NewBuffer(requestBody))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to warm up model %s: %w", model, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code while warming up model %s: %d", model, resp.StatusCode)
+		}
+		logger.Info("Model loaded", "model", model, "endpoint", baseURL, "duration", time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
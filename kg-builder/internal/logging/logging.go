@@ -0,0 +1,152 @@
+// Package logging sets up structured logging with slog: text or JSON output, and a level per module.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Output formats, set through LOG_FORMAT.
+const (
+	FormatText = "text" // key=value pairs
+	FormatJSON = "json" // One JSON object per line
+)
+
+var (
+	mutex        sync.RWMutex
+	base         slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	defaultLevel              = slog.LevelInfo
+	moduleLevels              = map[string]slog.Level{}
+	runID        string
+)
+
+// Setup makes slog, and the log package through it, write to standard error in the format at the levels of spec:
+// a default level, optionally followed by module=level pairs, e.g. "info,graph=debug,neo4j=warn".
+func Setup(spec, format string) error {
+	def, modules, err := ParseLevels(spec)
+	if err != nil {
+		return err
+	}
+	options := &slog.HandlerOptions{Level: slog.LevelDebug} // Module loggers filter by their own level
+	var handler slog.Handler
+	switch format {
+	case FormatText, "":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	mutex.Lock()
+	if runID != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("run", runID)})
+	}
+	base, defaultLevel, moduleLevels = handler, def, modules
+	mutex.Unlock()
+	slog.SetDefault(slog.New(&moduleHandler{})) // Records of the log package have no module and use the default level
+	return nil
+}
+
+// NewRunID returns a random correlation ID for a run of the builder or a command.
+func NewRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate run ID: %v", err)) // The system random source never fails in practice
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetRunID makes every record carry the correlation ID of the run as the run attribute. Call it before Setup.
+func SetRunID(id string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	runID = id
+}
+
+// RunID returns the correlation ID of the run, or an empty string if none is set. It is also recorded with LLM
+// answers and on what the run writes to Neo4j, so an incident can be traced from the graph back to the logs.
+func RunID() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return runID
+}
+
+// ParseLevels parses a level spec into the default level and the levels of the modules named in it.
+func ParseLevels(spec string) (slog.Level, map[string]slog.Level, error) {
+	def := slog.LevelInfo
+	modules := map[string]slog.Level{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		module, name, hasModule := strings.Cut(item, "=")
+		if !hasModule {
+			module, name = "", item
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return 0, nil, fmt.Errorf("invalid log level %q", item)
+		}
+		if hasModule {
+			modules[strings.TrimSpace(module)] = level
+		} else {
+			def = level
+		}
+	}
+	return def, modules, nil
+}
+
+// For returns the logger of a module. Its records carry a module attribute and are dropped below the module's
+// level. It can be called before Setup: records go to the handler current when they are logged.
+func For(module string) *slog.Logger {
+	return slog.New(&moduleHandler{module: module})
+}
+
+// moduleHandler filters records by the level of its module and passes them on to the current base handler.
+type moduleHandler struct {
+	module string
+	with   []func(slog.Handler) slog.Handler // Attributes and groups added to the logger, in order
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	min, ok := moduleLevels[h.module]
+	if !ok || h.module == "" {
+		min = defaultLevel
+	}
+	return level >= min
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, record slog.Record) error {
+	mutex.RLock()
+	handler := base
+	mutex.RUnlock()
+	if h.module != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("module", h.module)})
+	}
+	for _, with := range h.with {
+		handler = with(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.add(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	return h.add(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *moduleHandler) add(with func(slog.Handler) slog.Handler) *moduleHandler {
+	return &moduleHandler{module: h.module, with: append(append([]func(slog.Handler) slog.Handler{}, h.with...), with)}
+}
@@ -0,0 +1,96 @@
+package neo4j
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// CleanupCounts are the numbers of nodes a cleanup removes.
+type CleanupCounts struct {
+	Orphans  int64 // Concepts without relationships
+	Removals int64 // RelationRemoval records of relationships removed before the cutoff
+}
+
+// cleanupOrphans matches the concepts without relationships.
+const cleanupOrphans = `MATCH (c:Concept) WHERE NOT (c)--()`
+
+// cleanupRemovals matches the RelationRemoval records removed before $before.
+const cleanupRemovals = `MATCH (m:RelationRemoval) WHERE m.removedAt < $before`
+
+// CountCleanup returns what Cleanup would remove: the concepts without relationships if orphans is set, and the
+// RelationRemoval records removed before the cutoff unless it is zero.
+func CountCleanup(driver neo4j.Driver, orphans bool, removalsBefore time.Time) (CleanupCounts, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	counts, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		var counts CleanupCounts
+		count := func(match string, params map[string]interface{}) (int64, error) {
+			records, err := runQuery(tx, match+` RETURN count(*)`, params)
+			if err != nil {
+				return 0, err
+			}
+			n, _ := records[0].Values[0].(int64)
+			return n, nil
+		}
+		var err error
+		if orphans {
+			if counts.Orphans, err = count(cleanupOrphans, nil); err != nil {
+				return nil, err
+			}
+		}
+		if !removalsBefore.IsZero() {
+			params := map[string]interface{}{"before": removalsBefore}
+			if counts.Removals, err = count(cleanupRemovals, params); err != nil {
+				return nil, err
+			}
+		}
+		return counts, nil
+	})
+	if err != nil {
+		return CleanupCounts{}, fmt.Errorf("failed to count the nodes to clean up: %w", err)
+	}
+	return counts.(CleanupCounts), nil
+}
+
+// Cleanup deletes the concepts without relationships if orphans is set, and the RelationRemoval records removed
+// before the cutoff unless it is zero, in batches, and returns how many it deleted. Timelines and ego diffs lose the
+// removed relationships whose records are deleted.
+func Cleanup(driver neo4j.Driver, orphans bool, removalsBefore time.Time) (CleanupCounts, error) {
+	var counts CleanupCounts
+	if ReadOnly() {
+		return counts, ErrReadOnly
+	}
+
+	deleteAll := func(match, variable string, params map[string]interface{}) (int64, error) {
+		params["batch"] = migrationBatchSize
+		query := match + ` WITH ` + variable + ` LIMIT $batch DELETE ` + variable + ` RETURN count(*)`
+		var deleted int64
+		for {
+			n, err := migrateBatch(driver, query, params)
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+			if n == 0 {
+				return deleted, nil
+			}
+		}
+	}
+
+	var err error
+	if orphans {
+		if counts.Orphans, err = deleteAll(cleanupOrphans, "c", map[string]interface{}{}); err != nil {
+			return counts, fmt.Errorf("failed to delete concepts without relationships: %w", err)
+		}
+	}
+	if !removalsBefore.IsZero() {
+		params := map[string]interface{}{"before": removalsBefore}
+		if counts.Removals, err = deleteAll(cleanupRemovals, "m", params); err != nil {
+			return counts, fmt.Errorf("failed to delete removed relationship records: %w", err)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,193 @@
+package neo4j
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// distinctList is a Cypher expression removing duplicates from the list in `list`, keeping the first occurrence.
+const distinctList = `reduce(acc = [], x IN list | CASE WHEN x IN acc THEN acc ELSE acc + x END)`
+
+// MergeConcepts merges the duplicate concepts into the canonical one in a single transaction and returns the number
+// of duplicates that existed. The relationships of the duplicates are moved to the canonical concept with their
+// creation times and runs (relationships between the merged concepts are dropped rather than turned into self-loops),
+// the duplicates' names and aliases are added to its aliases and their tags to its tags, the merge is recorded in its
+// mergedFrom and mergedAt properties, and the duplicates are deleted with their relationships recorded as removed.
+// The canonical concept is created if it does not exist, so merging a single duplicate renames it.
+func MergeConcepts(driver neo4j.Driver, canonical string, duplicates []string) (int, error) {
+	if ReadOnly() {
+		return 0, ErrReadOnly
+	}
+	var names []interface{}
+	for _, d := range duplicates {
+		if d != canonical {
+			names = append(names, d)
+		}
+	}
+	if len(names) == 0 {
+		return 0, fmt.Errorf("no duplicates to merge into %q", canonical)
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	merged, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		params := removalParams(map[string]interface{}{"canonical": canonical, "duplicates": names}, RemovalMerge)
+
+		records, err := runQuery(tx, `
+            MATCH (d:Concept) WHERE d.name IN $duplicates
+            RETURN collect(d.name) AS found
+        `, params)
+		if err != nil {
+			return nil, err
+		}
+		found, _ := records[0].Values[0].([]interface{})
+		if len(found) == 0 {
+			return 0, nil
+		}
+
+		if _, err := runQuery(tx, `MERGE (:Concept {name: $canonical})`, params); err != nil {
+			return nil, err
+		}
+
+		// Move the relationships of the duplicates to the canonical concept
+		relationships, err := readRelationships(tx, names)
+		if err != nil {
+			return nil, err
+		}
+		rename := func(name string) string {
+			for _, d := range names {
+				if name == d {
+					return canonical
+				}
+			}
+			return name
+		}
+		var moved []movedRelationship
+		for _, r := range relationships {
+			r.From, r.To = rename(r.From), rename(r.To)
+			if r.From != r.To {
+				moved = append(moved, r)
+			}
+		}
+		if err := moveRelationships(tx, moved); err != nil {
+			return nil, err
+		}
+
+		queries := []string{
+			// Record aliases, tags and provenance
+			`MATCH (c:Concept {name: $canonical})
+             MATCH (d:Concept) WHERE d.name IN $duplicates
+             WITH c, collect(d) AS ds
+             WITH c, ds, [d IN ds | d.name] AS merged,
+                  coalesce(c.aliases, []) + [d IN ds | d.name] +
+                  reduce(a = [], d IN ds | a + coalesce(d.aliases, [])) AS list
+             WITH c, merged, list,
+                  coalesce(c.tags, []) + reduce(t = [], d IN ds | t + coalesce(d.tags, [])) AS tags
+             SET c.aliases = [x IN ` + distinctList + ` WHERE x <> c.name],
+                 c.tags = CASE WHEN size(tags) = 0 THEN c.tags
+                          ELSE reduce(acc = [], x IN tags | CASE WHEN x IN acc THEN acc ELSE acc + x END) END,
+                 c.mergedFrom = coalesce(c.mergedFrom, []) + merged,
+                 c.mergedAt = datetime()`,
+			// Record the relationships deleted with the duplicates
+			`MATCH (a:Concept)-[r]->(b:Concept) WHERE a.name IN $duplicates OR b.name IN $duplicates` + recordRemoval,
+			`MATCH (d:Concept) WHERE d.name IN $duplicates DETACH DELETE d`,
+		}
+		for _, query := range queries {
+			if _, err := runQuery(tx, query, params); err != nil {
+				return nil, err
+			}
+		}
+		return len(found), nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to merge concepts into %q: %w", canonical, err)
+	}
+	return merged.(int), nil
+}
+
+// SplitConcept splits an over-broad concept into the target concepts in a single transaction. Every relationship of
+// the concept is moved, with its creation time and run, to the target its neighbor is assigned to (assignments maps
+// neighbor names to targets, and every neighbor must be assigned), the targets record the split in their splitFrom
+// and splitAt properties, and the original concept is deleted with its relationships recorded as removed.
+func SplitConcept(driver neo4j.Driver, concept string, targets []string, assignments map[string]string) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	if len(targets) < 2 {
+		return fmt.Errorf("a concept must be split into at least two concepts")
+	}
+	valid := make(map[string]bool, len(targets))
+	var targetNames []interface{}
+	for _, t := range targets {
+		if t == concept {
+			return fmt.Errorf("target %q must differ from the concept being split", t)
+		}
+		valid[t] = true
+		targetNames = append(targetNames, t)
+	}
+	for neighbor, target := range assignments {
+		if !valid[target] {
+			return fmt.Errorf("%q is assigned to %q, which is not one of the targets", neighbor, target)
+		}
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		params := removalParams(map[string]interface{}{"concept": concept, "targets": targetNames}, RemovalSplit)
+		if _, err := runQuery(tx, `
+            UNWIND $targets AS name
+            MERGE (t:Concept {name: name})
+            SET t.splitFrom = $concept, t.splitAt = datetime()
+        `, params); err != nil {
+			return nil, err
+		}
+
+		// Move each relationship to the target its neighbor is assigned to
+		relationships, err := readRelationships(tx, []interface{}{concept})
+		if err != nil {
+			return nil, err
+		}
+		var moved []movedRelationship
+		for _, r := range relationships {
+			neighbor := r.To
+			if r.To == concept {
+				neighbor = r.From
+			}
+			if neighbor == concept {
+				continue // Self-loop
+			}
+			target, ok := assignments[neighbor]
+			if !ok {
+				return nil, fmt.Errorf("%q is not assigned to a target", neighbor)
+			}
+			if target == neighbor {
+				continue
+			}
+			if r.From == concept {
+				r.From = target
+			} else {
+				r.To = target
+			}
+			moved = append(moved, r)
+		}
+		if err := moveRelationships(tx, moved); err != nil {
+			return nil, err
+		}
+
+		if _, err := runQuery(tx, `
+            MATCH (a:Concept)-[r]->(b:Concept) WHERE a.name = $concept OR b.name = $concept
+        `+recordRemoval, params); err != nil {
+			return nil, err
+		}
+		_, err = runQuery(tx, `MATCH (c:Concept {name: $concept}) DETACH DELETE c`, params)
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to split %q: %w", concept, err)
+	}
+	return nil
+}
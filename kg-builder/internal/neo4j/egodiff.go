@@ -0,0 +1,152 @@
+package neo4j
+
+import (
+	"sort"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// EgoDiff is how the neighborhood of a concept changed between two times.
+type EgoDiff struct {
+	Concept string    `json:"concept"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	// Neighbors the concept had no relationship with at From but had at To
+	AddedNeighbors []string `json:"addedNeighbors"`
+	// Neighbors the concept had a relationship with at From but no longer had at To
+	RemovedNeighbors []string `json:"removedNeighbors"`
+	// Neighbors related to the concept at both times through different relationships
+	ChangedRelations []RelationChange `json:"changedRelations"`
+	// Relationships present at To but not at From
+	AddedRelationships []Relationship `json:"addedRelationships"`
+	// Relationships present at From but not at To
+	RemovedRelationships []Relationship `json:"removedRelationships"`
+	// Relationships without a creation time, which are assumed to predate both times
+	Undated int `json:"undated"`
+}
+
+// RelationChange is how the relationships between a concept and one of its neighbors changed.
+type RelationChange struct {
+	Neighbor string         `json:"neighbor"`
+	Before   []Relationship `json:"before"`
+	After    []Relationship `json:"after"`
+}
+
+// ConceptEgoDiff compares the neighborhood of the concept at two times. It replays the concept's timeline: a
+// relationship is present from its creation, or from when curation last moved it, until its recorded removal.
+func ConceptEgoDiff(driver neo4j.Driver, concept string, from, to time.Time) (*EgoDiff, error) {
+	events, err := ConceptTimeline(driver, concept)
+	if err != nil {
+		return nil, err
+	}
+	return diffTimeline(concept, events, from, to), nil
+}
+
+// diffTimeline compares the relationships of a concept's timeline present at from with those present at to.
+func diffTimeline(concept string, events []TimelineEvent, from, to time.Time) *EgoDiff {
+	diff := &EgoDiff{Concept: concept, From: from, To: to,
+		AddedNeighbors: []string{}, RemovedNeighbors: []string{}, ChangedRelations: []RelationChange{},
+		AddedRelationships: []Relationship{}, RemovedRelationships: []Relationship{}}
+
+	// Relationships are keyed by endpoints and type, as descriptions may change when a relationship is moved
+	before, after := map[Relationship]Relationship{}, map[Relationship]Relationship{}
+	for _, e := range events {
+		var start, end time.Time // A zero start is before any time and a zero end is never
+		switch e.Kind {
+		case TimelineRelationship:
+			start = e.At
+			if e.Since != nil {
+				start = *e.Since
+			}
+		case TimelineRemoved:
+			if e.Since != nil {
+				start = *e.Since
+			}
+			end = e.At
+		default:
+			continue
+		}
+		if start.IsZero() {
+			diff.Undated++
+		}
+		present := func(t time.Time) bool {
+			return !start.After(t) && (end.IsZero() || end.After(t))
+		}
+		r := *e.Relationship
+		key := Relationship{From: r.From, To: r.To, Type: r.Type}
+		if present(from) {
+			before[key] = r
+		}
+		if present(to) {
+			after[key] = r
+		}
+	}
+
+	neighbor := func(r Relationship) string {
+		if r.From == concept {
+			return r.To
+		}
+		return r.From
+	}
+	beforeByNeighbor, afterByNeighbor := map[string][]Relationship{}, map[string][]Relationship{}
+	for key, r := range before {
+		beforeByNeighbor[neighbor(r)] = append(beforeByNeighbor[neighbor(r)], key)
+		if _, ok := after[key]; !ok {
+			diff.RemovedRelationships = append(diff.RemovedRelationships, r)
+		}
+	}
+	for key, r := range after {
+		afterByNeighbor[neighbor(r)] = append(afterByNeighbor[neighbor(r)], key)
+		if _, ok := before[key]; !ok {
+			diff.AddedRelationships = append(diff.AddedRelationships, r)
+		}
+	}
+
+	for n, rs := range afterByNeighbor {
+		previous, ok := beforeByNeighbor[n]
+		if !ok {
+			diff.AddedNeighbors = append(diff.AddedNeighbors, n)
+			continue
+		}
+		sortRelationships(previous)
+		sortRelationships(rs)
+		if !sameRelationships(previous, rs) {
+			change := RelationChange{Neighbor: n}
+			for _, key := range previous {
+				change.Before = append(change.Before, before[key])
+			}
+			for _, key := range rs {
+				change.After = append(change.After, after[key])
+			}
+			diff.ChangedRelations = append(diff.ChangedRelations, change)
+		}
+	}
+	for n := range beforeByNeighbor {
+		if _, ok := afterByNeighbor[n]; !ok {
+			diff.RemovedNeighbors = append(diff.RemovedNeighbors, n)
+		}
+	}
+
+	sort.Strings(diff.AddedNeighbors)
+	sort.Strings(diff.RemovedNeighbors)
+	sort.Slice(diff.ChangedRelations, func(i, j int) bool {
+		return diff.ChangedRelations[i].Neighbor < diff.ChangedRelations[j].Neighbor
+	})
+	sortRelationships(diff.AddedRelationships)
+	sortRelationships(diff.RemovedRelationships)
+	return diff
+}
+
+// sameRelationships reports whether two sorted lists hold the same relationships.
+func sameRelationships(a, b []Relationship) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package neo4j
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// Graph is a snapshot of concepts and the relationships between them.
+type Graph struct {
+	Concepts      []ConceptNode // Sorted by name
+	Relationships []Relationship
+}
+
+// ConceptNode is a concept with its properties, such as tags, aliases and merge or split provenance.
+type ConceptNode struct {
+	Name       string
+	Properties map[string]interface{} // All properties but the name
+}
+
+// ReadGraph returns the whole graph, read in one transaction so the snapshot is consistent.
+func ReadGraph(driver neo4j.Driver) (*Graph, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	graph, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		concepts, err := readConceptNodes(tx, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		relationships, err := queryRelationships(tx, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		sortRelationships(relationships)
+		return &Graph{Concepts: concepts, Relationships: relationships}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the graph: %w", err)
+	}
+	return graph.(*Graph), nil
+}
+
+// readConceptNodes returns the concepts selected by the WHERE clause with their properties, sorted by name.
+func readConceptNodes(tx neo4j.Transaction, where string, params map[string]interface{}) ([]ConceptNode, error) {
+	records, err := runQuery(tx, conceptsQuery(where), params)
+	if err != nil {
+		return nil, err
+	}
+
+	concepts := make([]ConceptNode, 0, len(records))
+	for _, record := range records {
+		concepts = append(concepts, conceptFromRecord(record))
+	}
+	return concepts, nil
+}
+
+// conceptsQuery returns the query reading the concepts selected by the WHERE clause, sorted by name.
+func conceptsQuery(where string) string {
+	return `
+        MATCH (c:Concept)
+        ` + where + `
+        RETURN c.name AS name, properties(c) ORDER BY name
+    `
+}
+
+// conceptFromRecord converts a record of conceptsQuery.
+func conceptFromRecord(record *neo4j.Record) ConceptNode {
+	c := ConceptNode{}
+	c.Name, _ = record.Values[0].(string)
+	c.Properties, _ = record.Values[1].(map[string]interface{})
+	delete(c.Properties, "name")
+	return c
+}
+
+// StreamGraph calls concept for every concept, sorted by name, then relationship for every relationship, as they
+// are read, so the graph is never held in memory. Both are read in one transaction so the snapshot is consistent.
+// Unlike the other reads it is not retried on transient errors, since the callbacks may already have run.
+func StreamGraph(driver neo4j.Driver, concept func(ConceptNode) error, relationship func(Relationship) error) error {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction()
+	if err != nil {
+		return fmt.Errorf("failed to read the graph: %w", err)
+	}
+	defer tx.Close()
+
+	err = streamQuery(tx, conceptsQuery(""), nil, func(record *neo4j.Record) error {
+		return concept(conceptFromRecord(record))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the concepts: %w", err)
+	}
+	err = streamQuery(tx, relationshipsQuery(""), nil, func(record *neo4j.Record) error {
+		return relationship(relationshipFromRecord(record))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the relationships: %w", err)
+	}
+	return nil
+}
+
+// sortRelationships orders relationships by source, target and type, so exports are stable.
+func sortRelationships(relationships []Relationship) {
+	sort.Slice(relationships, func(i, j int) bool {
+		a, b := relationships[i], relationships[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+}
+
+// ReadNeighborhood returns the concepts within depth relationships of the concept, in either direction, and the
+// relationships between them. At most limit concepts are returned, the closest first.
+func ReadNeighborhood(driver neo4j.Driver, concept string, depth, limit int) (*Graph, error) {
+	if depth < 1 || limit < 1 {
+		return nil, fmt.Errorf("depth and limit must be positive")
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	graph, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		// Variable-length bounds cannot be parameters; depth is a validated integer
+		records, err := runQuery(tx, fmt.Sprintf(`
+            MATCH (c:Concept {name: $concept})
+            OPTIONAL MATCH p = (c)-[*1..%d]-(n:Concept) WHERE n <> c
+            WITH c, n, min(length(p)) AS distance
+            ORDER BY distance, n.name
+            RETURN c.name, collect(n.name)[..$neighbors]
+        `, depth), map[string]interface{}{"concept": concept, "neighbors": limit - 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("concept %q not found", concept)
+		}
+		names := []interface{}{concept}
+		if neighbors, ok := records[0].Values[1].([]interface{}); ok {
+			names = append(names, neighbors...)
+		}
+
+		params := map[string]interface{}{"names": names}
+		concepts, err := readConceptNodes(tx, "WHERE c.name IN $names", params)
+		if err != nil {
+			return nil, err
+		}
+		relationships, err := queryRelationships(tx, "WHERE a.name IN $names AND b.name IN $names", params)
+		if err != nil {
+			return nil, err
+		}
+		sortRelationships(relationships)
+		return &Graph{Concepts: concepts, Relationships: relationships}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the neighborhood of %s: %w", concept, err)
+	}
+	return graph.(*Graph), nil
+}
@@ -0,0 +1,118 @@
+package neo4j
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// IndexAdvice is an index recommended for a property the queries of this package look nodes or relationships up by.
+type IndexAdvice struct {
+	Entity    string // Node label or relationship type
+	Property  string
+	Reason    string // Queries relying on the index
+	Statement string // Cypher creating the index
+	Exists    bool   // An index on the property already exists
+}
+
+// indexAdvice lists the lookups made by the queries in this package.
+var indexAdvice = []IndexAdvice{
+	{
+		Entity:    "Concept",
+		Property:  "name",
+		Reason:    "every write MERGEs concepts by name and every lookup MATCHes them by name; uniqueness also stops concurrent MERGEs from creating duplicates",
+		Statement: "CREATE CONSTRAINT concept_name_unique IF NOT EXISTS FOR (c:Concept) REQUIRE c.name IS UNIQUE",
+	},
+	{
+		Entity:    "RELATED_TO",
+		Property:  "type",
+		Reason:    "relationships are MERGEd by type, and merges and splits rewire them by type",
+		Statement: "CREATE INDEX related_to_type IF NOT EXISTS FOR ()-[r:RELATED_TO]-() ON (r.type)",
+	},
+	{
+		Entity:    "RelationRemoval",
+		Property:  "from",
+		Reason:    "timelines and ego-network diffs look up the relationships removed from a concept",
+		Statement: "CREATE INDEX relation_removal_from IF NOT EXISTS FOR (m:RelationRemoval) ON (m.from)",
+	},
+	{
+		Entity:    "RelationRemoval",
+		Property:  "to",
+		Reason:    "timelines and ego-network diffs look up the relationships removed to a concept",
+		Statement: "CREATE INDEX relation_removal_to IF NOT EXISTS FOR (m:RelationRemoval) ON (m.to)",
+	},
+}
+
+// AdviseIndexes returns the recommended indexes, each marked with whether the database already has it.
+func AdviseIndexes(driver neo4j.Driver) ([]IndexAdvice, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	existing, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, "SHOW INDEXES YIELD labelsOrTypes, properties", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		indexed := make(map[string]bool)
+		for _, record := range records {
+			entities, _ := record.Values[0].([]interface{})
+			properties, _ := record.Values[1].([]interface{})
+			if len(entities) != 1 || len(properties) == 0 {
+				continue
+			}
+			// A composite index also serves lookups by its first property
+			indexed[fmt.Sprintf("%v.%v", entities[0], properties[0])] = true
+		}
+		return indexed, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	indexed := existing.(map[string]bool)
+	advice := recommendedIndexes()
+	for i, a := range advice {
+		advice[i].Exists = indexed[a.Entity+"."+a.Property]
+	}
+	return advice, nil
+}
+
+// SchemaStatements returns the Cypher creating the recommended indexes, for scripts run against other databases.
+func SchemaStatements() []string {
+	var statements []string
+	for _, a := range recommendedIndexes() {
+		statements = append(statements, a.Statement)
+	}
+	return statements
+}
+
+// recommendedIndexes returns the index advice that applies to the configured relation strategy.
+func recommendedIndexes() []IndexAdvice {
+	var advice []IndexAdvice
+	for _, a := range indexAdvice {
+		if a.Entity == relatedToType && RelationStrategy() != RelationStrategyProperty {
+			continue // Only the property strategy looks relationships up by a property
+		}
+		advice = append(advice, a)
+	}
+	return advice
+}
+
+// CreateIndex creates the recommended index.
+func CreateIndex(driver neo4j.Driver, advice IndexAdvice) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return runQuery(tx, advice.Statement, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create index on %s.%s: %w", advice.Entity, advice.Property, err)
+	}
+	return nil
+}
@@ -2,41 +2,166 @@ package neo4j
 
 import (
 	"fmt"
-	"log"
 	"net/url"
 	"os"
 	"time"
 
+	"kg-builder/internal/logging"
+
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
 
+// logger is the logger of the neo4j module.
+var logger = logging.For("neo4j")
+
 // SetupNeo4jConnection establishes a connection to the Neo4j database with retry logic to handle connection failures.
 func SetupNeo4jConnection() (neo4j.Driver, error) {
 	return connectToNeo4jWithRetry(5, 5*time.Second)
 }
 
-// CreateRelationship creates a relationship between two concepts in the Neo4j database using a Cypher query.
+// CreateRelationship creates a relationship between two concepts in the Neo4j database, stored with the configured
+// relation strategy. The relation is sanitized into a relation type; free-form text that had to be changed is kept
+// as the description.
 func CreateRelationship(driver neo4j.Driver, from, to, relation string) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	relationType, description := normalizeRelation(relation)
+
 	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close()
 
 	// Write a transaction to create the relationship
 	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return nil, mergeRelationship(tx, from, to, relationType, description)
+	})
+	// Return the error from the transaction
+	return err
+}
+
+// CreateRelationshipsBatch creates the relationships in a single transaction, with one UNWIND query instead of a
+// transaction per relationship. The Type of each relationship is the relation text, normalized like CreateRelationship
+// does; free-form text that had to be changed is kept as the description unless one is given.
+func CreateRelationshipsBatch(driver neo4j.Driver, relationships []Relationship) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	if len(relationships) == 0 {
+		return nil
+	}
+
+	normalized := make([]Relationship, len(relationships))
+	for i, r := range relationships {
+		relationType, description := normalizeRelation(r.Type)
+		if r.Description != "" {
+			description = r.Description
+		}
+		normalized[i] = Relationship{From: r.From, To: r.To, Type: relationType, Description: description}
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return nil, mergeRelationships(tx, normalized)
+	})
+	return err
+}
+
+// CreateConcepts creates the named concepts in a single transaction, unless they exist.
+func CreateConcepts(driver neo4j.Driver, names []string) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return runQuery(tx, "UNWIND $names AS name MERGE (c:Concept {name: name}) ON CREATE SET c.runId = $runId, c.createdAt = datetime()",
+			map[string]interface{}{"names": names, "runId": nullIfEmpty(logging.RunID())})
+	})
+	return err
+}
+
+// GetRelatedConceptNames returns the names of the concepts directly related to the given concept, in either direction.
+func GetRelatedConceptNames(driver neo4j.Driver, concept string) ([]string, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	names, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
 		query := `
-            MERGE (a:Concept {name: $from})
-            MERGE (b:Concept {name: $to})
-            MERGE (a)-[r:RELATED_TO {type: $relation}]->(b)
+            MATCH (:Concept {name: $name})--(n:Concept)
+            RETURN DISTINCT n.name AS name
         `
-		params := map[string]interface{}{
-			"from":     from,
-			"to":       to,
-			"relation": relation,
+		records, err := runQuery(tx, query, map[string]interface{}{"name": concept})
+		if err != nil {
+			return nil, err
 		}
-		_, err := tx.Run(query, params)
-		return nil, err // Return the error from the transaction
+		return recordNames(records), nil
 	})
-	// Return the error from the transaction
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return names.([]string), nil
+}
+
+// GetConceptNames returns the names of all concepts, sorted.
+func GetConceptNames(driver neo4j.Driver) ([]string, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	names, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, "MATCH (c:Concept) RETURN c.name AS name ORDER BY name", nil)
+		if err != nil {
+			return nil, err
+		}
+		return recordNames(records), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names.([]string), nil
+}
+
+// CountConcepts returns the number of Concept nodes in the database.
+func CountConcepts(driver neo4j.Driver) (int64, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	count, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, "MATCH (c:Concept) RETURN count(c) AS count", nil)
+		if err != nil {
+			return nil, err
+		}
+		return records[0].Values[0], nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count.(int64), nil
+}
+
+// recordNames returns the string values of the first column of the records.
+func recordNames(records []*neo4j.Record) []string {
+	var names []string
+	for _, record := range records {
+		if name, ok := record.Values[0].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// nullIfEmpty maps an empty string to a Cypher null, so no empty property is stored.
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
 }
 
 // connectToNeo4jWithRetry attempts to connect to the Neo4j database multiple times with retry logic.
@@ -64,22 +189,22 @@ func connectToNeo4jWithRetry(maxRetries int, retryInterval time.Duration) (neo4j
 		return nil, fmt.Errorf("NEO4J_PASSWORD environment variable is not set")
 	}
 
-	log.Printf("Attempting to connect to Neo4j at %s", neo4jURI)
+	logger.Info("Connecting to Neo4j", "uri", neo4jURI)
 
 	// Attempt to create a driver with retry logic
 	var driver neo4j.Driver
 	for i := 0; i < maxRetries; i++ {
 		driver, err = neo4j.NewDriver(neo4jURI, neo4j.BasicAuth(neo4jUser, neo4jPassword, ""))
 		if err == nil {
-			log.Printf("Driver created successfully, verifying connectivity...")
+			logger.Debug("Driver created, verifying connectivity")
 			err = driver.VerifyConnectivity()
 			if err == nil {
-				log.Printf("Successfully connected to Neo4j on attempt %d", i+1)
+				logger.Info("Connected to Neo4j", "attempt", i+1)
 				return driver, nil
 			}
 		}
 		// Log the failure and wait before retrying
-		log.Printf("Failed to connect to Neo4j (attempt %d/%d): %v", i+1, maxRetries, err)
+		logger.Warn("Failed to connect to Neo4j", "attempt", i+1, "attempts", maxRetries, "error", err)
 		time.Sleep(retryInterval)
 	}
 	// If all attempts fail, return an error
@@ -0,0 +1,108 @@
+package neo4j
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// slowQueryLimit is the number of slowest queries kept for the summary.
+const slowQueryLimit = 10
+
+// SlowQuery is a query that took at least the slow query threshold.
+type SlowQuery struct {
+	Query    string   // Query text with whitespace collapsed
+	Params   []string // Parameter names; the values are not kept
+	Duration time.Duration
+	Rows     int
+}
+
+var (
+	slowQueryMutex     sync.Mutex
+	slowQueryThreshold time.Duration // Zero disables slow query logging
+	slowQueries        []SlowQuery   // Slowest queries so far, slowest first
+)
+
+// SetSlowQueryThreshold makes queries taking at least the threshold get logged and kept for SlowestQueries.
+// Zero disables slow query logging.
+func SetSlowQueryThreshold(threshold time.Duration) {
+	slowQueryMutex.Lock()
+	defer slowQueryMutex.Unlock()
+	slowQueryThreshold = threshold
+}
+
+// SlowestQueries returns the slowest queries logged so far, slowest first.
+func SlowestQueries() []SlowQuery {
+	slowQueryMutex.Lock()
+	defer slowQueryMutex.Unlock()
+	return append([]SlowQuery(nil), slowQueries...)
+}
+
+// runQuery runs the query in the transaction and returns all its records, logging the query if it was slow.
+func runQuery(tx neo4j.Transaction, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	start := time.Now()
+	result, err := tx.Run(query, params)
+	if err != nil {
+		return nil, err
+	}
+	records, err := result.Collect()
+	if err != nil {
+		return nil, err
+	}
+	recordQuery(query, params, time.Since(start), len(records))
+	return records, nil
+}
+
+// streamQuery runs a query in the transaction and calls fn for each record as it arrives, instead of collecting the
+// result. The recorded duration includes the time fn takes.
+func streamQuery(tx neo4j.Transaction, query string, params map[string]interface{}, fn func(*neo4j.Record) error) error {
+	start := time.Now()
+	result, err := tx.Run(query, params)
+	if err != nil {
+		return err
+	}
+	rows := 0
+	for result.Next() {
+		if err := fn(result.Record()); err != nil {
+			return err
+		}
+		rows++
+	}
+	if err := result.Err(); err != nil {
+		return err
+	}
+	recordQuery(query, params, time.Since(start), rows)
+	return nil
+}
+
+// recordQuery logs the query and adds it to the slowest queries if it took at least the threshold.
+func recordQuery(query string, params map[string]interface{}, duration time.Duration, rows int) {
+	slowQueryMutex.Lock()
+	defer slowQueryMutex.Unlock()
+
+	if slowQueryThreshold <= 0 || duration < slowQueryThreshold {
+		return
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	q := SlowQuery{Query: strings.Join(strings.Fields(query), " "), Params: names, Duration: duration, Rows: rows}
+	logger.Warn("Slow Neo4j query", "duration", duration.Round(time.Millisecond), "rows", rows, "params", names, "query", q.Query)
+
+	i := sort.Search(len(slowQueries), func(i int) bool { return slowQueries[i].Duration < duration })
+	if i >= slowQueryLimit {
+		return
+	}
+	slowQueries = append(slowQueries, SlowQuery{})
+	copy(slowQueries[i+1:], slowQueries[i:])
+	slowQueries[i] = q
+	if len(slowQueries) > slowQueryLimit {
+		slowQueries = slowQueries[:slowQueryLimit]
+	}
+}
@@ -0,0 +1,21 @@
+package neo4j
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned by every write while the graph is in read-only mode.
+var ErrReadOnly = errors.New("the graph is in read-only mode (GRAPH_READ_ONLY)")
+
+var readOnly atomic.Bool
+
+// SetReadOnly switches the read-only mode on or off. While it is on, writes fail with ErrReadOnly and reads continue.
+func SetReadOnly(on bool) {
+	readOnly.Store(on)
+}
+
+// ReadOnly reports whether the graph is in read-only mode.
+func ReadOnly() bool {
+	return readOnly.Load()
+}
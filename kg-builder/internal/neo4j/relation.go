@@ -0,0 +1,83 @@
+package neo4j
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultRelationType is used when the LLM's relation text has no usable characters or is not on the allowlist.
+const DefaultRelationType = "RelatedTo"
+
+// maxRelationTypeLength bounds the length of a sanitized relation type, in characters.
+const maxRelationTypeLength = 64
+
+var (
+	allowlistMutex    sync.RWMutex
+	relationAllowlist map[string]bool // Nil allows every sanitized type
+)
+
+// SetRelationAllowlist restricts the relation types written to the graph. Types not on the list are stored as
+// DefaultRelationType, with the original text kept in the relationship's description. An empty list allows all types.
+func SetRelationAllowlist(types []string) {
+	allowlistMutex.Lock()
+	defer allowlistMutex.Unlock()
+
+	if len(types) == 0 {
+		relationAllowlist = nil
+		return
+	}
+	relationAllowlist = map[string]bool{DefaultRelationType: true}
+	for _, t := range types {
+		relationAllowlist[SanitizeRelationType(t)] = true
+	}
+}
+
+// SanitizeRelationType turns free-form relation text from the LLM into an UpperCamelCase identifier made of letters
+// and digits in any script, e.g. "is a subset of" becomes "IsASubsetOf" and "größer als" becomes "GrößerAls". The
+// result starts with a letter and is safe to use as a property value or relationship type; text starting with a
+// number, such as "123 abc", becomes DefaultRelationType rather than losing the number.
+func SanitizeRelationType(relation string) string {
+	if strings.ToUpper(relation) == relation {
+		relation = strings.ToLower(relation) // SCREAMING_SNAKE_CASE, so "IS_A" becomes "IsA"
+	}
+
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(relation, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	}) {
+		first, size := utf8.DecodeRuneInString(word)
+		if b.Len() == 0 && !unicode.IsLetter(first) {
+			return DefaultRelationType // Identifiers must start with a letter
+		}
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+
+	sanitized := []rune(b.String())
+	if len(sanitized) > maxRelationTypeLength {
+		sanitized = sanitized[:maxRelationTypeLength]
+	}
+	if len(sanitized) == 0 {
+		return DefaultRelationType
+	}
+	return string(sanitized)
+}
+
+// normalizeRelation returns the relation type to store and, when it differs from the LLM's text, the original text
+// to keep as the relationship's description.
+func normalizeRelation(relation string) (relationType string, description string) {
+	relationType = SanitizeRelationType(relation)
+
+	allowlistMutex.RLock()
+	if relationAllowlist != nil && !relationAllowlist[relationType] {
+		relationType = DefaultRelationType
+	}
+	allowlistMutex.RUnlock()
+
+	if relationType != relation {
+		description = strings.TrimSpace(relation)
+	}
+	return relationType, description
+}
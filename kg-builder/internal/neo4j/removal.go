@@ -0,0 +1,26 @@
+package neo4j
+
+import "kg-builder/internal/logging"
+
+// Reasons a relationship was removed, as recorded on RelationRemoval nodes.
+const (
+	RemovalMerge     = "merge"     // A concept it connected was merged into another one
+	RemovalSplit     = "split"     // A concept it connected was split into others
+	RemovalRename    = "rename"    // Its relation type was renamed
+	RemovalMigration = "migration" // Its relation type was sanitized while changing the relation strategy
+)
+
+// recordRemoval is a Cypher clause recording the removal of relationship r from a to b as a RelationRemoval node,
+// with the relation type, the creation time and run it had, and the time it took its endpoints and type. The query
+// must provide the parameters added by removalParams.
+const recordRemoval = `
+    CREATE (:RelationRemoval {from: a.name, to: b.name, type: ` + relationTypeExpression + `,
+        description: r.description, createdAt: r.createdAt, activeFrom: coalesce(r.movedAt, r.createdAt),
+        reason: $reason, runId: $runId, removedAt: datetime()})`
+
+// removalParams adds the parameters of recordRemoval to params and returns it.
+func removalParams(params map[string]interface{}, reason string) map[string]interface{} {
+	params["reason"] = reason
+	params["runId"] = nullIfEmpty(logging.RunID())
+	return params
+}
@@ -0,0 +1,153 @@
+package neo4j
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// RelationRename is a relation type rename applied by RenameRelations, as recorded in the graph.
+type RelationRename struct {
+	From          string
+	To            string
+	Relationships int64
+	AppliedAt     time.Time
+}
+
+// CountRelationType returns the number of relationships of the relation type, whichever strategy they were stored with.
+func CountRelationType(driver neo4j.Driver, relationType string) (int64, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	count, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, `
+            MATCH (:Concept)-[r]->(:Concept)
+            WHERE `+relationTypeExpression+` = $type
+            RETURN count(r)
+        `, map[string]interface{}{"type": relationType})
+		if err != nil {
+			return nil, err
+		}
+		count, _ := records[0].Values[0].(int64)
+		return count, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %q relationships: %w", relationType, err)
+	}
+	return count.(int64), nil
+}
+
+// RenameRelations rewrites the relationships of each old relation type in mapping to its new type, in batches,
+// keeping the strategy each relationship was stored with. Several old types may map to the same new type to merge
+// them. Each rename is recorded as a RelationRename node and each rewritten relationship as a RelationRemoval. It
+// returns the number of relationships rewritten by old type.
+func RenameRelations(driver neo4j.Driver, mapping map[string]string) (map[string]int64, error) {
+	if ReadOnly() {
+		return nil, ErrReadOnly
+	}
+
+	olds := make([]string, 0, len(mapping))
+	for old, to := range mapping {
+		if old == relatedToType || to == relatedToType {
+			return nil, fmt.Errorf("%s is reserved for the property strategy and cannot be renamed", relatedToType)
+		}
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+
+	renamed := make(map[string]int64, len(mapping))
+	for _, old := range olds {
+		to := mapping[old]
+		if old == to {
+			continue
+		}
+		params := removalParams(map[string]interface{}{"old": old, "new": to, "batch": migrationBatchSize}, RemovalRename)
+		queries := []string{
+			`
+            MATCH (a:Concept)-[r:RELATED_TO {type: $old}]->(b:Concept)
+            WITH a, r, b LIMIT $batch
+            MERGE (a)-[n:RELATED_TO {type: $new}]->(b)
+            ON CREATE SET n.description = r.description, n.runId = r.runId, n.createdAt = r.createdAt,
+                          n.movedAt = datetime()
+            ` + recordRemoval + `
+            DELETE r
+            RETURN count(*)
+        `,
+			`
+            MATCH (a:Concept)-[r:` + quoteIdentifier(old) + `]->(b:Concept)
+            WITH a, r, b LIMIT $batch
+            MERGE (a)-[n:` + quoteIdentifier(to) + `]->(b)
+            ON CREATE SET n.description = r.description, n.runId = r.runId, n.createdAt = r.createdAt,
+                          n.movedAt = datetime()
+            ` + recordRemoval + `
+            DELETE r
+            RETURN count(*)
+        `,
+		}
+		for _, query := range queries {
+			for {
+				n, err := migrateBatch(driver, query, params)
+				if err != nil {
+					return renamed, fmt.Errorf("failed to rename %q relationships: %w", old, err)
+				}
+				renamed[old] += n
+				if n == 0 {
+					break
+				}
+			}
+		}
+
+		if err := recordRename(driver, old, to, renamed[old]); err != nil {
+			return renamed, err
+		}
+	}
+	return renamed, nil
+}
+
+// recordRename stores an applied rename so the history of the relation types can be reviewed later.
+func recordRename(driver neo4j.Driver, from, to string, count int64) error {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return runQuery(tx, `
+            CREATE (:RelationRename {from: $from, to: $to, relationships: $count, appliedAt: datetime()})
+        `, map[string]interface{}{"from": from, "to": to, "count": count})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the rename of %q: %w", from, err)
+	}
+	return nil
+}
+
+// RelationRenames returns the renames applied so far, oldest first.
+func RelationRenames(driver neo4j.Driver) ([]RelationRename, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	renames, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, `
+            MATCH (m:RelationRename)
+            RETURN m.from, m.to, m.relationships, m.appliedAt ORDER BY m.appliedAt
+        `, nil)
+		if err != nil {
+			return nil, err
+		}
+		renames := make([]RelationRename, 0, len(records))
+		for _, record := range records {
+			var r RelationRename
+			r.From, _ = record.Values[0].(string)
+			r.To, _ = record.Values[1].(string)
+			r.Relationships, _ = record.Values[2].(int64)
+			r.AppliedAt, _ = record.Values[3].(time.Time)
+			renames = append(renames, r)
+		}
+		return renames, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the relation renames: %w", err)
+	}
+	return renames.([]RelationRename), nil
+}
@@ -0,0 +1,76 @@
+package neo4j
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// SampledRelationship is a relationship picked for a labeling dataset, with the descriptions of its concepts.
+type SampledRelationship struct {
+	Relationship
+	FromDescription string
+	ToDescription   string
+}
+
+// RelationTypeCounts returns the number of relationships of each relation type, whichever strategy they were stored with.
+func RelationTypeCounts(driver neo4j.Driver) (map[string]int64, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	counts, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return relationTypeCounts(tx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count relationships by type: %w", err)
+	}
+	return counts.(map[string]int64), nil
+}
+
+// relationTypeCounts counts the relationships of each relation type within the transaction.
+func relationTypeCounts(tx neo4j.Transaction) (map[string]int64, error) {
+	records, err := runQuery(tx, `
+        MATCH (:Concept)-[r]->(:Concept)
+        RETURN `+relationTypeExpression+` AS type, count(*)
+    `, nil)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(records))
+	for _, record := range records {
+		t, _ := record.Values[0].(string)
+		n, _ := record.Values[1].(int64)
+		counts[t] += n
+	}
+	return counts, nil
+}
+
+// SampleRelationships returns up to n relationships of the relation type, picked at random.
+func SampleRelationships(driver neo4j.Driver, relationType string, n int) ([]SampledRelationship, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	sample, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, `
+            MATCH (a:Concept)-[r]->(b:Concept)
+            WHERE `+relationTypeExpression+` = $type
+            WITH a, r, b ORDER BY rand() LIMIT $n
+            RETURN a.name, b.name, `+relationTypeExpression+`, r.description, a.description, b.description
+        `, map[string]interface{}{"type": relationType, "n": n})
+		if err != nil {
+			return nil, err
+		}
+		sample := make([]SampledRelationship, 0, len(records))
+		for _, record := range records {
+			s := SampledRelationship{Relationship: relationshipFromRecord(record)}
+			s.FromDescription, _ = record.Values[4].(string)
+			s.ToDescription, _ = record.Values[5].(string)
+			sample = append(sample, s)
+		}
+		return sample, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample %s relationships: %w", relationType, err)
+	}
+	return sample.([]SampledRelationship), nil
+}
@@ -0,0 +1,57 @@
+package neo4j
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// ServerInfo describes the Neo4j server and the plugins installed in it.
+type ServerInfo struct {
+	Version string
+	Edition string
+	APOC    bool // APOC procedures are installed
+	GDS     bool // Graph Data Science procedures are installed
+}
+
+// ConnectOnce connects to Neo4j without retrying, for checks that should fail fast.
+func ConnectOnce() (neo4j.Driver, error) {
+	return connectToNeo4jWithRetry(1, 0)
+}
+
+// GetServerInfo returns the version and edition of the Neo4j server and which plugins it has.
+func GetServerInfo(driver neo4j.Driver) (ServerInfo, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	info, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		var info ServerInfo
+		records, err := runQuery(tx, `
+            CALL dbms.components() YIELD name, versions, edition
+            WHERE name = 'Neo4j Kernel'
+            RETURN versions[0], edition
+        `, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 {
+			info.Version, _ = records[0].Values[0].(string)
+			info.Edition, _ = records[0].Values[1].(string)
+		}
+
+		records, err = runQuery(tx, `SHOW PROCEDURES YIELD name`, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range recordNames(records) {
+			info.APOC = info.APOC || strings.HasPrefix(name, "apoc.")
+			info.GDS = info.GDS || strings.HasPrefix(name, "gds.")
+		}
+		return info, nil
+	})
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf("failed to get the server info: %w", err)
+	}
+	return info.(ServerInfo), nil
+}
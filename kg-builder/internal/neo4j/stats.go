@@ -0,0 +1,97 @@
+package neo4j
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// degreeBucketBounds are the lower bounds of the degree distribution buckets; each bucket ends below the next bound.
+var degreeBucketBounds = []int64{0, 1, 2, 5, 10, 25, 50, 100}
+
+// ExtendedStats describes the shape of the graph.
+type ExtendedStats struct {
+	Concepts      int64            `json:"concepts"`
+	Relationships int64            `json:"relationships"`
+	Orphans       int64            `json:"orphans"` // Concepts without relationships
+	RelationTypes map[string]int64 `json:"relationTypes"`
+	Degrees       []DegreeBucket   `json:"degrees"`
+	TopConcepts   []ConceptDegree  `json:"topConcepts"` // Most connected concepts first
+}
+
+// DegreeBucket is the number of concepts whose degree is within [Min, Max]. Max is -1 for the last, open bucket.
+type DegreeBucket struct {
+	Min      int64 `json:"min"`
+	Max      int64 `json:"max"`
+	Concepts int64 `json:"concepts"`
+}
+
+// GetExtendedStats returns the counts, relation type histogram, degree distribution and the top most connected
+// concepts of the graph, read in one transaction so they agree with each other.
+func GetExtendedStats(driver neo4j.Driver, top int) (*ExtendedStats, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	stats, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		stats := &ExtendedStats{Degrees: make([]DegreeBucket, len(degreeBucketBounds))}
+		for i, min := range degreeBucketBounds {
+			stats.Degrees[i] = DegreeBucket{Min: min, Max: -1}
+			if i+1 < len(degreeBucketBounds) {
+				stats.Degrees[i].Max = degreeBucketBounds[i+1] - 1
+			}
+		}
+
+		var err error
+		if stats.RelationTypes, err = relationTypeCounts(tx); err != nil {
+			return nil, err
+		}
+		for _, n := range stats.RelationTypes {
+			stats.Relationships += n
+		}
+
+		records, err := runQuery(tx, `
+            MATCH (c:Concept)
+            RETURN size((c)--()) AS degree, count(*) AS concepts
+        `, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			degree, _ := record.Values[0].(int64)
+			n, _ := record.Values[1].(int64)
+			stats.Concepts += n
+			if degree == 0 {
+				stats.Orphans += n
+			}
+			for i := len(stats.Degrees) - 1; i >= 0; i-- {
+				if degree >= stats.Degrees[i].Min {
+					stats.Degrees[i].Concepts += n
+					break
+				}
+			}
+		}
+
+		records, err = runQuery(tx, `
+            MATCH (c:Concept)
+            WITH c.name AS name, size((c)--()) AS degree
+            ORDER BY degree DESC, name
+            LIMIT $top
+            RETURN name, degree
+        `, map[string]interface{}{"top": top})
+		if err != nil {
+			return nil, err
+		}
+		stats.TopConcepts = make([]ConceptDegree, 0, len(records))
+		for _, record := range records {
+			var d ConceptDegree
+			d.Name, _ = record.Values[0].(string)
+			d.Degree, _ = record.Values[1].(int64)
+			stats.TopConcepts = append(stats.TopConcepts, d)
+		}
+		return stats, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph statistics: %w", err)
+	}
+	return stats.(*ExtendedStats), nil
+}
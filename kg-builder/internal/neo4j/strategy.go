@@ -0,0 +1,334 @@
+package neo4j
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"kg-builder/internal/logging"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// Relation strategies, i.e. how the relation between two concepts is stored.
+const (
+	RelationStrategyProperty = "property" // One RELATED_TO relationship type with the relation in its type property
+	RelationStrategyType     = "type"     // The relation is the relationship type, e.g. (a)-[:IsA]->(b)
+)
+
+// relatedToType is the relationship type used by the property strategy.
+const relatedToType = "RELATED_TO"
+
+// relationTypeExpression is the relation type of relationship r in Cypher, whichever strategy it was stored with.
+const relationTypeExpression = "coalesce(CASE type(r) WHEN 'RELATED_TO' THEN r.type ELSE type(r) END, '" + DefaultRelationType + "')"
+
+// migrationBatchSize is the number of relationships converted, or nodes deleted, per transaction by MigrateRelations,
+// RenameRelations and Cleanup.
+const migrationBatchSize = 1000
+
+var (
+	strategyMutex    sync.RWMutex
+	relationStrategy = RelationStrategyProperty
+)
+
+// SetRelationStrategy selects how relations are stored by subsequent writes.
+func SetRelationStrategy(strategy string) error {
+	if strategy != RelationStrategyProperty && strategy != RelationStrategyType {
+		return fmt.Errorf("unknown relation strategy %q", strategy)
+	}
+	strategyMutex.Lock()
+	defer strategyMutex.Unlock()
+	relationStrategy = strategy
+	return nil
+}
+
+// RelationStrategy returns the strategy relations are stored with.
+func RelationStrategy() string {
+	strategyMutex.RLock()
+	defer strategyMutex.RUnlock()
+	return relationStrategy
+}
+
+// Relationship is a relationship between two concepts, independent of the strategy it is stored with.
+type Relationship struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// readRelationships returns the relationships of the named concepts in either direction, whichever strategy
+// they were stored with, together with their provenance so they can be moved.
+func readRelationships(tx neo4j.Transaction, names []interface{}) ([]movedRelationship, error) {
+	records, err := runQuery(tx, `
+        MATCH (a:Concept)-[r]->(b:Concept)
+        WHERE a.name IN $names OR b.name IN $names
+        RETURN a.name, b.name, CASE type(r) WHEN 'RELATED_TO' THEN r.type ELSE type(r) END, r.description,
+               r.createdAt, r.runId
+    `, map[string]interface{}{"names": names})
+	if err != nil {
+		return nil, err
+	}
+
+	relationships := make([]movedRelationship, 0, len(records))
+	for _, record := range records {
+		relationships = append(relationships, movedRelationship{
+			Relationship: relationshipFromRecord(record),
+			createdAt:    record.Values[4],
+			runID:        record.Values[5],
+		})
+	}
+	return relationships, nil
+}
+
+// queryRelationships returns the relationships between concepts selected by the WHERE clause, whichever strategy
+// they were stored with.
+func queryRelationships(tx neo4j.Transaction, where string, params map[string]interface{}) ([]Relationship, error) {
+	records, err := runQuery(tx, relationshipsQuery(where), params)
+	if err != nil {
+		return nil, err
+	}
+
+	relationships := make([]Relationship, 0, len(records))
+	for _, record := range records {
+		relationships = append(relationships, relationshipFromRecord(record))
+	}
+	return relationships, nil
+}
+
+// relationshipsQuery returns the query reading the relationships between concepts selected by the WHERE clause.
+func relationshipsQuery(where string) string {
+	return `
+        MATCH (a:Concept)-[r]->(b:Concept)
+        ` + where + `
+        RETURN a.name, b.name, CASE type(r) WHEN 'RELATED_TO' THEN r.type ELSE type(r) END, r.description
+    `
+}
+
+// relationshipFromRecord converts a record of relationshipsQuery.
+func relationshipFromRecord(record *neo4j.Record) Relationship {
+	r := Relationship{Type: DefaultRelationType}
+	r.From, _ = record.Values[0].(string)
+	r.To, _ = record.Values[1].(string)
+	if t, ok := record.Values[2].(string); ok && t != "" {
+		r.Type = t
+	}
+	r.Description, _ = record.Values[3].(string)
+	return r
+}
+
+// mergeRelationship creates the relationship and its concepts with the configured strategy, unless they exist.
+func mergeRelationship(tx neo4j.Transaction, from, to, relationType, description string) error {
+	return mergeRelationships(tx, []Relationship{{From: from, To: to, Type: relationType, Description: description}})
+}
+
+// mergeRelationships creates the relationships and their concepts with the configured strategy, unless they exist,
+// using one UNWIND query per relationship type (or a single one with the property strategy).
+func mergeRelationships(tx neo4j.Transaction, relationships []Relationship) error {
+	moved := make([]movedRelationship, len(relationships))
+	for i, r := range relationships {
+		moved[i] = movedRelationship{Relationship: r}
+	}
+	return writeRelationships(tx, moved, `r.runId = $runId, r.createdAt = datetime()`)
+}
+
+// movedRelationship is a relationship being moved to other concepts or another type, with the provenance it keeps.
+type movedRelationship struct {
+	Relationship
+	createdAt interface{}
+	runID     interface{}
+}
+
+// moveRelationships creates the relationships like mergeRelationships, but keeps the creation time and run of the
+// relationships they replace and records when they were moved, so timelines still show when each was learned.
+func moveRelationships(tx neo4j.Transaction, relationships []movedRelationship) error {
+	return writeRelationships(tx, relationships,
+		`r.runId = row.runId, r.createdAt = row.createdAt, r.movedAt = datetime()`)
+}
+
+// writeRelationships merges the relationships and their concepts with the configured strategy, setting the
+// provenance clause on the relationships it creates.
+func writeRelationships(tx neo4j.Transaction, relationships []movedRelationship, provenance string) error {
+	params := map[string]interface{}{"runId": nullIfEmpty(logging.RunID())}
+	if RelationStrategy() != RelationStrategyType {
+		params["rows"] = relationshipRows(relationships)
+		_, err := runQuery(tx, `
+            UNWIND $rows AS row
+            MERGE (a:Concept {name: row.from}) ON CREATE SET a.runId = $runId, a.createdAt = datetime()
+            MERGE (b:Concept {name: row.to}) ON CREATE SET b.runId = $runId, b.createdAt = datetime()
+            MERGE (a)-[r:RELATED_TO {type: row.relation}]->(b)
+            ON CREATE SET r.description = row.description, `+provenance+`
+        `, params)
+		return err
+	}
+
+	// Relationship types cannot be parameters, so each type gets its own query; the type is quoted so any text is safe
+	var types []string
+	byType := make(map[string][]movedRelationship)
+	for _, r := range relationships {
+		if _, ok := byType[r.Type]; !ok {
+			types = append(types, r.Type)
+		}
+		byType[r.Type] = append(byType[r.Type], r)
+	}
+	for _, t := range types {
+		params["rows"] = relationshipRows(byType[t])
+		_, err := runQuery(tx, `
+            UNWIND $rows AS row
+            MERGE (a:Concept {name: row.from}) ON CREATE SET a.runId = $runId, a.createdAt = datetime()
+            MERGE (b:Concept {name: row.to}) ON CREATE SET b.runId = $runId, b.createdAt = datetime()
+            MERGE (a)-[r:`+quoteIdentifier(t)+`]->(b)
+            ON CREATE SET r.description = row.description, `+provenance+`
+        `, params)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relationshipRows converts relationships to the parameter rows of the UNWIND queries.
+func relationshipRows(relationships []movedRelationship) []interface{} {
+	rows := make([]interface{}, 0, len(relationships))
+	for _, r := range relationships {
+		rows = append(rows, map[string]interface{}{
+			"from":        r.From,
+			"to":          r.To,
+			"relation":    r.Type,
+			"description": nullIfEmpty(r.Description),
+			"createdAt":   r.createdAt,
+			"runId":       r.runID,
+		})
+	}
+	return rows
+}
+
+// quoteIdentifier quotes a label or relationship type for use in Cypher.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// RelationsToMigrate returns, by relation type, the number of relationships not stored with the configured strategy.
+func RelationsToMigrate(driver neo4j.Driver) (map[string]int64, error) {
+	query := `
+        MATCH (:Concept)-[r:RELATED_TO]->(:Concept)
+        RETURN coalesce(r.type, '') AS type, count(*) AS count
+    `
+	if RelationStrategy() == RelationStrategyProperty {
+		query = `
+            MATCH (:Concept)-[r]->(:Concept) WHERE type(r) <> 'RELATED_TO'
+            RETURN type(r) AS type, count(*) AS count
+        `
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	counts, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, query, nil)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64, len(records))
+		for _, record := range records {
+			t, _ := record.Values[0].(string)
+			n, _ := record.Values[1].(int64)
+			counts[t] = n
+		}
+		return counts, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count relationships to migrate: %w", err)
+	}
+	return counts.(map[string]int64), nil
+}
+
+// MigrateRelations converts the relationships stored with the other strategy to the configured one, in batches,
+// and returns how many were converted. Relation types that are not valid identifiers are sanitized and the original
+// text kept in the description, as CreateRelationship does; the relationships whose type changed are recorded as
+// RelationRemovals.
+func MigrateRelations(driver neo4j.Driver) (int64, error) {
+	if ReadOnly() {
+		return 0, ErrReadOnly
+	}
+
+	types, err := RelationsToMigrate(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	var migrated int64
+	for rawType := range types {
+		relationType := SanitizeRelationType(rawType)
+		description, moved, removal := "", "", ""
+		if relationType != rawType {
+			// The relation type changes, so the old one is recorded as removed
+			description = rawType
+			moved = ", n.movedAt = datetime()"
+			removal = recordRemoval
+		}
+
+		var query string
+		if RelationStrategy() == RelationStrategyType {
+			query = `
+                MATCH (a:Concept)-[r:RELATED_TO]->(b:Concept) WHERE coalesce(r.type, '') = $raw
+                WITH a, r, b LIMIT $batch
+                MERGE (a)-[n:` + quoteIdentifier(relationType) + `]->(b)
+                ON CREATE SET n.description = coalesce(r.description, $description), n.runId = r.runId,
+                              n.createdAt = r.createdAt` + moved + `
+                ` + removal + `
+                DELETE r
+                RETURN count(*)
+            `
+		} else {
+			query = `
+                MATCH (a:Concept)-[r:` + quoteIdentifier(rawType) + `]->(b:Concept)
+                WITH a, r, b LIMIT $batch
+                MERGE (a)-[n:RELATED_TO {type: $type}]->(b)
+                ON CREATE SET n.description = coalesce(r.description, $description), n.runId = r.runId,
+                              n.createdAt = r.createdAt` + moved + `
+                ` + removal + `
+                DELETE r
+                RETURN count(*)
+            `
+		}
+		params := removalParams(map[string]interface{}{
+			"raw":         rawType,
+			"type":        relationType,
+			"description": nullIfEmpty(description),
+			"batch":       migrationBatchSize,
+		}, RemovalMigration)
+
+		for {
+			n, err := migrateBatch(driver, query, params)
+			if err != nil {
+				return migrated, fmt.Errorf("failed to migrate %q relationships: %w", rawType, err)
+			}
+			migrated += n
+			if n == 0 {
+				break
+			}
+		}
+	}
+	return migrated, nil
+}
+
+// migrateBatch runs one batch of a migration query in its own transaction and returns the number of relationships
+// it converted.
+func migrateBatch(driver neo4j.Driver, query string, params map[string]interface{}) (int64, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	n, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		count, _ := records[0].Values[0].(int64)
+		return count, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n.(int64), nil
+}
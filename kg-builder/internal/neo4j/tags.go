@@ -0,0 +1,148 @@
+package neo4j
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// TagConcepts adds the tag to the named concepts and returns how many did not have it yet. Tags are free-form
+// labels kept in the tags property of a concept, e.g. "verified" or "needs-work".
+func TagConcepts(driver neo4j.Driver, tag string, names []string) (int64, error) {
+	return updateTags(driver, `
+        UNWIND $names AS name
+        MATCH (c:Concept {name: name})
+        WHERE NOT $tag IN coalesce(c.tags, [])
+        SET c.tags = coalesce(c.tags, []) + $tag
+        RETURN count(c)
+    `, tag, names)
+}
+
+// UntagConcepts removes the tag from the named concepts and returns how many had it.
+func UntagConcepts(driver neo4j.Driver, tag string, names []string) (int64, error) {
+	return updateTags(driver, `
+        UNWIND $names AS name
+        MATCH (c:Concept {name: name})
+        WHERE $tag IN coalesce(c.tags, [])
+        SET c.tags = [t IN c.tags WHERE t <> $tag]
+        RETURN count(c)
+    `, tag, names)
+}
+
+// updateTags runs a tag update query and returns the number of concepts it changed.
+func updateTags(driver neo4j.Driver, query, tag string, names []string) (int64, error) {
+	if ReadOnly() {
+		return 0, ErrReadOnly
+	}
+	if tag == "" {
+		return 0, fmt.Errorf("tag must not be empty")
+	}
+	params := map[string]interface{}{"tag": tag, "names": stringsToInterfaces(names)}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	changed, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		count, _ := records[0].Values[0].(int64)
+		return count, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update tag %q: %w", tag, err)
+	}
+	return changed.(int64), nil
+}
+
+// ConceptsWithTag returns the names of the concepts carrying the tag, sorted.
+func ConceptsWithTag(driver neo4j.Driver, tag string) ([]string, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	names, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, `
+            MATCH (c:Concept) WHERE $tag IN coalesce(c.tags, [])
+            RETURN c.name AS name ORDER BY name
+        `, map[string]interface{}{"tag": tag})
+		if err != nil {
+			return nil, err
+		}
+		return recordNames(records), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names.([]string), nil
+}
+
+// TagCounts returns the number of concepts carrying each tag.
+func TagCounts(driver neo4j.Driver) (map[string]int64, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	counts, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, `
+            MATCH (c:Concept) UNWIND coalesce(c.tags, []) AS tag
+            RETURN tag, count(*) AS count
+        `, nil)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64, len(records))
+		for _, record := range records {
+			tag, _ := record.Values[0].(string)
+			count, _ := record.Values[1].(int64)
+			counts[tag] = count
+		}
+		return counts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts.(map[string]int64), nil
+}
+
+// ConceptDegree is a concept with the number of its relationships.
+type ConceptDegree struct {
+	Name   string `json:"name"`
+	Degree int64  `json:"degree"`
+}
+
+// ConceptDegrees returns every concept with its degree, sorted by name.
+func ConceptDegrees(driver neo4j.Driver) ([]ConceptDegree, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	degrees, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := runQuery(tx, `
+            MATCH (c:Concept)
+            RETURN c.name AS name, size((c)--()) AS degree ORDER BY name
+        `, nil)
+		if err != nil {
+			return nil, err
+		}
+		degrees := make([]ConceptDegree, 0, len(records))
+		for _, record := range records {
+			var d ConceptDegree
+			d.Name, _ = record.Values[0].(string)
+			d.Degree, _ = record.Values[1].(int64)
+			degrees = append(degrees, d)
+		}
+		return degrees, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return degrees.([]ConceptDegree), nil
+}
+
+// stringsToInterfaces converts a string slice into a list parameter.
+func stringsToInterfaces(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
@@ -0,0 +1,130 @@
+package neo4j
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// Kinds of timeline events.
+const (
+	TimelineCreated      = "created"      // The concept was created
+	TimelineRelationship = "relationship" // A relationship of the concept was created
+	TimelineRemoved      = "removed"      // A relationship of the concept was removed by curation
+	TimelineMerged       = "merged"       // Duplicates were merged into the concept
+	TimelineSplit        = "split"        // The concept was created by splitting another one
+)
+
+// TimelineEvent is something that happened to a concept. At is zero for relationships and concepts written before
+// creation times were recorded.
+type TimelineEvent struct {
+	At           time.Time     `json:"at"`
+	Kind         string        `json:"kind"`
+	RunID        string        `json:"runId,omitempty"`
+	Relationship *Relationship `json:"relationship,omitempty"` // The relationship created or removed
+	Concepts     []string      `json:"concepts,omitempty"`     // The concepts merged or split from
+	Reason       string        `json:"reason,omitempty"`       // Why the relationship was removed, for removed events
+	// When the relationship took its endpoints and type: for relationship events, when curation moved it after its
+	// creation; for removed events, when the removed relationship was created or last moved
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// ConceptTimeline returns what the graph recorded about the concept in chronological order: its creation, the
+// creation of each of its current relationships, its merges and splits, and the relationships curation removed.
+// The timeline of a concept that was merged away or split only has its removed relationships.
+func ConceptTimeline(driver neo4j.Driver, concept string) ([]TimelineEvent, error) {
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	events, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		params := map[string]interface{}{"concept": concept}
+		records, err := runQuery(tx, `
+            MATCH (c:Concept {name: $concept})
+            RETURN c.createdAt, c.runId, c.mergedAt, c.mergedFrom, c.splitAt, c.splitFrom
+        `, params)
+		if err != nil {
+			return nil, err
+		}
+		var events []TimelineEvent
+		exists := len(records) > 0
+		if exists {
+			values := records[0].Values
+			if at, ok := values[0].(time.Time); ok {
+				runID, _ := values[1].(string)
+				events = append(events, TimelineEvent{At: at, Kind: TimelineCreated, RunID: runID})
+			}
+			if at, ok := values[2].(time.Time); ok {
+				events = append(events, TimelineEvent{At: at, Kind: TimelineMerged, Concepts: stringList(values[3])})
+			}
+			if at, ok := values[4].(time.Time); ok {
+				from, _ := values[5].(string)
+				events = append(events, TimelineEvent{At: at, Kind: TimelineSplit, Concepts: []string{from}})
+			}
+		}
+
+		records, err = runQuery(tx, `
+            MATCH (a:Concept)-[r]->(b:Concept) WHERE a.name = $concept OR b.name = $concept
+            RETURN a.name, b.name, `+relationTypeExpression+`, r.description, r.createdAt, r.runId, r.movedAt
+        `, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			r := relationshipFromRecord(record)
+			event := TimelineEvent{Kind: TimelineRelationship, Relationship: &r}
+			event.At, _ = record.Values[4].(time.Time)
+			event.RunID, _ = record.Values[5].(string)
+			if movedAt, ok := record.Values[6].(time.Time); ok {
+				event.Since = &movedAt
+			}
+			events = append(events, event)
+		}
+
+		records, err = runQuery(tx, `
+            MATCH (m:RelationRemoval) WHERE m.from = $concept OR m.to = $concept
+            RETURN m.from, m.to, m.type, m.description, m.removedAt, m.runId, m.activeFrom, m.reason
+        `, params)
+		if err != nil {
+			return nil, err
+		}
+		if !exists && len(records) == 0 {
+			return nil, fmt.Errorf("concept %q not found", concept)
+		}
+		for _, record := range records {
+			r := relationshipFromRecord(record)
+			event := TimelineEvent{Kind: TimelineRemoved, Relationship: &r}
+			event.At, _ = record.Values[4].(time.Time)
+			event.RunID, _ = record.Values[5].(string)
+			if since, ok := record.Values[6].(time.Time); ok {
+				event.Since = &since
+			}
+			event.Reason, _ = record.Values[7].(string)
+			events = append(events, event)
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the timeline of %q: %w", concept, err)
+	}
+
+	timeline := events.([]TimelineEvent)
+	sort.SliceStable(timeline, func(i, j int) bool {
+		if !timeline[i].At.Equal(timeline[j].At) {
+			return timeline[i].At.Before(timeline[j].At)
+		}
+		return timeline[i].Kind == TimelineCreated && timeline[j].Kind != TimelineCreated
+	})
+	return timeline, nil
+}
+
+// stringList converts a list property into strings.
+func stringList(value interface{}) []string {
+	values, _ := value.([]interface{})
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		list = append(list, fmt.Sprint(v))
+	}
+	return list
+}
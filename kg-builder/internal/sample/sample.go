@@ -0,0 +1,25 @@
+// Package sample bundles a small curated knowledge graph for demos and development.
+package sample
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"kg-builder/internal/models"
+)
+
+// The relationships of an earlier build from the "Artificial Intelligence" seed, in the format the LLM returns them:
+// each entry relates Name to RelatedTo with Relation.
+//
+//go:embed sample.json
+var sampleJSON []byte
+
+// Relationships returns the relationships of the sample graph.
+func Relationships() ([]models.Concept, error) {
+	var concepts []models.Concept
+	if err := json.Unmarshal(sampleJSON, &concepts); err != nil {
+		return nil, fmt.Errorf("failed to parse sample graph: %w", err)
+	}
+	return concepts, nil
+}
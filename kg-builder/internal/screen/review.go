@@ -0,0 +1,152 @@
+package screen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Item is a relationship held back for review.
+type Item struct {
+	ID        int       `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Relation  string    `json:"relation"`
+	Reason    string    `json:"reason"`
+	FlaggedAt time.Time `json:"flaggedAt"`
+}
+
+// Queue is a file of relationships held back for review, one JSON item per line.
+type Queue struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// OpenQueue returns the review queue stored at path, creating its directory.
+func OpenQueue(path string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create review queue directory: %w", err)
+	}
+	return &Queue{path: path}, nil
+}
+
+// Add appends a relationship to the queue and returns its item.
+func (q *Queue) Add(from, to, relation, reason string) (Item, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	items, err := q.read()
+	if err != nil {
+		return Item{}, err
+	}
+	item := Item{ID: 1, From: from, To: to, Relation: relation, Reason: reason, FlaggedAt: time.Now().UTC()}
+	for _, i := range items {
+		if i.ID >= item.ID {
+			item.ID = i.ID + 1
+		}
+	}
+
+	f, err := os.OpenFile(q.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to open review queue: %w", err)
+	}
+	defer f.Close()
+	data, err := json.Marshal(item)
+	if err != nil {
+		return Item{}, err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return Item{}, fmt.Errorf("failed to write review queue: %w", err)
+	}
+	return item, nil
+}
+
+// Items returns the queued items in the order they were flagged.
+func (q *Queue) Items() ([]Item, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.read()
+}
+
+// Remove takes the items with the given IDs off the queue and returns them. IDs not in the queue are ignored.
+func (q *Queue) Remove(ids []int) ([]Item, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	items, err := q.read()
+	if err != nil {
+		return nil, err
+	}
+	remove := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	var removed, kept []Item
+	for _, i := range items {
+		if remove[i.ID] {
+			removed = append(removed, i)
+		} else {
+			kept = append(kept, i)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, q.write(kept)
+}
+
+// read loads the items of the queue file; a missing file is an empty queue. The caller must hold the mutex.
+func (q *Queue) read() ([]Item, error) {
+	f, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open review queue: %w", err)
+	}
+	defer f.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return nil, fmt.Errorf("invalid review queue line %d: %w", line, err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review queue: %w", err)
+	}
+	return items, nil
+}
+
+// write replaces the queue file with the items. The caller must hold the mutex.
+func (q *Queue) write(items []Item) error {
+	tmp := q.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write review queue: %w", err)
+	}
+	encoder := json.NewEncoder(f)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to write review queue: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write review queue: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
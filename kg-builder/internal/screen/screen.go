@@ -0,0 +1,93 @@
+// Package screen flags LLM output containing personal data or offensive words before it is written to the graph,
+// and keeps the flagged relationships in a review queue.
+package screen
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Detectors that can be enabled through GRAPH_SCREEN_DETECTORS.
+const (
+	DetectorEmail = "email" // Email addresses
+	DetectorPhone = "phone" // Phone numbers
+)
+
+var detectorPatterns = map[string]*regexp.Regexp{
+	DetectorEmail: regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`),
+	DetectorPhone: regexp.MustCompile(`(\+\d{1,3}[\s.-]?)?(\(\d{2,4}\)|\d{2,4})[\s.-]?\d{3,4}[\s.-]\d{3,4}\b`), // Three groups, so year ranges pass
+}
+
+// Screener checks texts against the enabled detectors and a wordlist.
+type Screener struct {
+	detectors []string
+	words     map[string]bool // Lower-case words and phrases
+}
+
+// New returns a screener with the given detectors and the words of the wordlist file, or nil if neither is set. The
+// file holds one word or phrase per line; blank lines and lines starting with # are ignored.
+func New(detectors []string, wordlistFile string) (*Screener, error) {
+	if len(detectors) == 0 && wordlistFile == "" {
+		return nil, nil
+	}
+	s := &Screener{words: make(map[string]bool)}
+	for _, d := range detectors {
+		if _, ok := detectorPatterns[d]; !ok {
+			return nil, fmt.Errorf("unknown detector %q", d)
+		}
+		s.detectors = append(s.detectors, d)
+	}
+	if wordlistFile == "" {
+		return s, nil
+	}
+
+	f, err := os.Open(wordlistFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			s.words[strings.Join(words(line), " ")] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	return s, nil
+}
+
+// Check returns why the text is flagged, or an empty string if it passes.
+func (s *Screener) Check(text string) string {
+	if s == nil {
+		return ""
+	}
+	for _, d := range s.detectors {
+		if detectorPatterns[d].MatchString(text) {
+			return "contains a possible " + d
+		}
+	}
+	// Match every run of consecutive words, so wordlist phrases are found too
+	w := words(text)
+	for i := range w {
+		for j := i + 1; j <= len(w); j++ {
+			if s.words[strings.Join(w[i:j], " ")] {
+				return "contains the listed word " + strings.Join(w[i:j], " ")
+			}
+		}
+	}
+	return ""
+}
+
+// words splits the text into lower-case words.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
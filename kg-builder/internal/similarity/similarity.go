@@ -0,0 +1,158 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases the name, replaces punctuation with spaces and strips a plural "s" from each word,
+// so that "Neural Networks" and "neural-network" normalize to the same string.
+func Normalize(name string) string {
+	return strings.Join(Tokens(name), " ")
+}
+
+// Tokens returns the normalized words of the name.
+func Tokens(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, f := range fields {
+		if len(f) > 3 && strings.HasSuffix(f, "s") && !strings.HasSuffix(f, "ss") {
+			fields[i] = strings.TrimSuffix(f, "s")
+		}
+	}
+	return fields
+}
+
+// Levenshtein returns the edit distance between a and b, counted in runes.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// LevenshteinRatio returns 1 minus the edit distance of the normalized names divided by the longer length,
+// so identical names score 1 and completely different names score close to 0.
+func LevenshteinRatio(a, b string) float64 {
+	na, nb := Normalize(a), Normalize(b)
+	longest := len([]rune(na))
+	if n := len([]rune(nb)); n > longest {
+		longest = n
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(na, nb))/float64(longest)
+}
+
+// TokenOverlap returns the Jaccard similarity of the normalized word sets of a and b.
+func TokenOverlap(a, b string) float64 {
+	ta, tb := Tokens(a), Tokens(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	union := len(set)
+	intersection := 0
+	seen := make(map[string]bool, len(tb))
+	for _, t := range tb {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if set[t] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+	return float64(intersection) / float64(union)
+}
+
+// Score returns the lexical similarity of two concept names in [0, 1], the higher of the Levenshtein ratio and the token overlap.
+func Score(a, b string) float64 {
+	return math.Max(LevenshteinRatio(a, b), TokenOverlap(a, b))
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if either has zero length.
+func Cosine(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// Pair is a pair of concept names with their similarity scores.
+type Pair struct {
+	A, B         string
+	Levenshtein  float64  // LevenshteinRatio of the names
+	TokenOverlap float64  // TokenOverlap of the names
+	Embedding    *float64 // Cosine similarity of the embeddings of the names; nil when they were not embedded
+	Score        float64  // Highest of the other scores
+}
+
+// Duplicates compares every pair of names and returns the pairs scoring at least threshold, most similar first.
+// vectors holds the embedding of each name, so pairs with different spellings but the same meaning are found too,
+// or is nil to compare the names lexically only.
+func Duplicates(names []string, vectors [][]float64, threshold float64) []Pair {
+	var pairs []Pair
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			a, b := names[i], names[j]
+			p := Pair{A: a, B: b, Levenshtein: LevenshteinRatio(a, b), TokenOverlap: TokenOverlap(a, b)}
+			p.Score = math.Max(p.Levenshtein, p.TokenOverlap)
+			if vectors != nil {
+				cosine := Cosine(vectors[i], vectors[j])
+				p.Embedding = &cosine
+				p.Score = math.Max(p.Score, cosine)
+			}
+			if p.Score >= threshold {
+				pairs = append(pairs, p)
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,109 @@
+// Package synthetic generates a deterministic pseudo-random ontology that can stand in for the LLM, so the graph
+// builder and Neo4j can be exercised at scale without any model.
+package synthetic
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"kg-builder/internal/models"
+)
+
+// relations are the relation types used in the synthetic ontology.
+var relations = []string{"IsA", "PartOf", "HasPart", "Causes", "Enables", "Requires", "Precedes", "RelatedTo"}
+
+// syllables make up the pseudo-words of the concept names.
+var syllables = []string{
+	"ka", "lo", "mi", "ne", "ru", "sa", "te", "vo", "zi", "bra", "cho", "dre",
+	"fen", "gal", "hor", "jun", "kel", "mor", "nix", "pra", "qua", "ros", "tal", "ver",
+}
+
+// mineProbability is the probability that two random concepts are related.
+const mineProbability = 0.3
+
+// Generator answers related-concept and relationship questions from a synthetic ontology of Size concepts,
+// numbered 0 to Size-1. Concept i has the children i*RelatedCount+1 to i*RelatedCount+RelatedCount, so the
+// ontology is a tree reachable from concept 0, with a few cross-links per concept. The same seed always yields
+// the same ontology.
+type Generator struct {
+	size         int
+	relatedCount int
+	seed         int64
+}
+
+// NewGenerator creates a generator of an ontology with size concepts, each expanding into relatedCount concepts.
+func NewGenerator(size, relatedCount int, seed int64) *Generator {
+	return &Generator{size: size, relatedCount: relatedCount, seed: seed}
+}
+
+// GetRelatedConcepts returns the related concepts of a synthetic concept. Concepts not generated by the ontology,
+// such as the seed concept, are treated as its root.
+func (g *Generator) GetRelatedConcepts(concept string) ([]models.Concept, error) {
+	i, ok := g.index(concept)
+	if !ok {
+		i = 0
+	}
+	rng := g.rand(int64(i))
+
+	var related []models.Concept
+	for c := 1; c <= g.relatedCount; c++ {
+		child := i*g.relatedCount + c
+		if child >= g.size {
+			break
+		}
+		related = append(related, models.Concept{Name: g.Name(child), Relation: relations[rng.Intn(len(relations))], RelatedTo: concept})
+	}
+	// Cross-links to random concepts elsewhere in the ontology
+	for l := 0; l < 2; l++ {
+		other := rng.Intn(g.size)
+		if other == i {
+			continue
+		}
+		related = append(related, models.Concept{Name: g.Name(other), Relation: relations[rng.Intn(len(relations))], RelatedTo: concept})
+	}
+	return related, nil
+}
+
+// MineRelationship decides deterministically whether two concepts are related, and how.
+func (g *Generator) MineRelationship(concept1, concept2 string) (*models.Concept, error) {
+	h := fnv.New64a()
+	h.Write([]byte(concept1 + "\x00" + concept2))
+	rng := g.rand(int64(h.Sum64()))
+	if rng.Float64() >= mineProbability {
+		return nil, nil // No relationship found
+	}
+	return &models.Concept{Name: concept2, Relation: relations[rng.Intn(len(relations))], RelatedTo: concept1}, nil
+}
+
+// Name returns the name of concept i: a pseudo-word, so names are not lexically similar, followed by the base-36
+// index, which makes names unique and lets the generator recognize its concepts.
+func (g *Generator) Name(i int) string {
+	rng := g.rand(int64(i))
+	var word strings.Builder
+	for s := 0; s < 3; s++ {
+		word.WriteString(syllables[rng.Intn(len(syllables))])
+	}
+	name := word.String()
+	return fmt.Sprintf("%s%s %s", strings.ToUpper(name[:1]), name[1:], strconv.FormatInt(int64(i), 36))
+}
+
+// index returns the number of a concept generated by the ontology.
+func (g *Generator) index(concept string) (int, bool) {
+	space := strings.LastIndexByte(concept, ' ')
+	if space < 0 {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(concept[space+1:], 36, 64)
+	if err != nil || i < 0 || int(i) >= g.size || g.Name(int(i)) != concept {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// rand returns a random source determined by the generator's seed and the given value.
+func (g *Generator) rand(value int64) *rand.Rand {
+	return rand.New(rand.NewSource(g.seed*1_000_003 + value))
+}
@@ -0,0 +1,199 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Record operations stored in the log.
+const (
+	opAdd    = "add"    // A relationship is about to be written
+	opCommit = "commit" // The relationship with the same ID was written
+)
+
+// Record is a relationship awaiting commit to Neo4j.
+type Record struct {
+	ID       uint64 `json:"id"`
+	Op       string `json:"op"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// Log is an append-only file of relationships awaiting commit. A relationship is appended (and synced to disk)
+// before it is written to Neo4j and marked committed afterwards, so a crash in between never loses it.
+type Log struct {
+	mutex   sync.Mutex
+	path    string
+	file    *os.File
+	nextID  uint64
+	pending map[uint64]Record
+}
+
+// Open opens or creates the log at path. Records left uncommitted by a previous run are kept and returned by Pending.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	l := &Log{path: path, nextID: 1, pending: make(map[uint64]Record)} // ID 0 is never used, so callers can use it for "not logged"
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	if err := l.compact(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Pending returns the uncommitted records in the order they were appended.
+func (l *Log) Pending() []Record {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	records := make([]Record, 0, len(l.pending))
+	for _, r := range l.pending {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records
+}
+
+// Append records a relationship before it is written and returns its ID.
+func (l *Log) Append(from, to, relation string) (uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	r := Record{ID: l.nextID, Op: opAdd, From: from, To: to, Relation: relation}
+	if err := l.write(r, true); err != nil {
+		return 0, err
+	}
+	l.nextID++
+	l.pending[r.ID] = r
+	return r.ID, nil
+}
+
+// Commit marks the relationship as written. The commit is not synced: losing it only means the idempotent write
+// is replayed once more.
+func (l *Log) Commit(id uint64) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.pending[id]; !ok {
+		return nil
+	}
+	if err := l.write(Record{ID: id, Op: opCommit}, false); err != nil {
+		return err
+	}
+	delete(l.pending, id)
+	return nil
+}
+
+// Close compacts the log down to the uncommitted records and closes it.
+func (l *Log) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	return l.rewrite()
+}
+
+// load reads the existing log, if any, keeping the records without a commit.
+func (l *Log) load() error {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open WAL: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // A torn final line from a crash mid-append
+		}
+		switch r.Op {
+		case opAdd:
+			l.pending[r.ID] = r
+		case opCommit:
+			delete(l.pending, r.ID)
+		}
+		if r.ID >= l.nextID {
+			l.nextID = r.ID + 1
+		}
+	}
+	return scanner.Err()
+}
+
+// compact rewrites the log with only the uncommitted records and reopens it for appending.
+func (l *Log) compact() error {
+	if err := l.rewrite(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open WAL: %w", err)
+	}
+	l.file = f
+	return nil
+}
+
+// rewrite atomically replaces the log file with the uncommitted records.
+func (l *Log) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), ".wal-*")
+	if err != nil {
+		return fmt.Errorf("failed to compact WAL: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	ids := make([]uint64, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		data, _ := json.Marshal(l.pending[id])
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to compact WAL: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to compact WAL: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to compact WAL: %w", err)
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
+
+// write appends a record to the log file, optionally syncing it to disk.
+func (l *Log) write(r Record, sync bool) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to WAL: %w", err)
+	}
+	if sync {
+		if err := l.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync WAL: %w", err)
+		}
+	}
+	return nil
+}
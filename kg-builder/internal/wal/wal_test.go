@@ -0,0 +1,125 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpenLoadsUncommittedRecords(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []Record
+		wantNext uint64
+	}{
+		{"missing log", "", []Record{}, 1},
+		{
+			"committed records dropped",
+			`{"id":1,"op":"add","from":"a","to":"b","relation":"r"}` + "\n" +
+				`{"id":2,"op":"add","from":"b","to":"c","relation":"r"}` + "\n" +
+				`{"id":1,"op":"commit"}` + "\n",
+			[]Record{{ID: 2, Op: opAdd, From: "b", To: "c", Relation: "r"}},
+			3,
+		},
+		{
+			"torn final line skipped",
+			`{"id":1,"op":"add","from":"a","to":"b","relation":"r"}` + "\n" + `{"id":2,"op":"ad`,
+			[]Record{{ID: 1, Op: opAdd, From: "a", To: "b", Relation: "r"}},
+			2,
+		},
+		{
+			"IDs continue after the committed ones",
+			`{"id":1,"op":"add","from":"a","to":"b","relation":"r"}` + "\n" + `{"id":1,"op":"commit"}` + "\n",
+			[]Record{},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wal", "relations.wal")
+			if tt.contents != "" {
+				os.MkdirAll(filepath.Dir(path), 0o755)
+				if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer l.Close()
+
+			if got := l.Pending(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pending() = %+v, want %+v", got, tt.want)
+			}
+			id, err := l.Append("x", "y", "r")
+			if err != nil {
+				t.Fatalf("Append() error = %v", err)
+			}
+			if id != tt.wantNext {
+				t.Errorf("Append() = %d, want %d", id, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestCommitAndCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relations.wal")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	first, _ := l.Append("a", "b", "r")
+	second, _ := l.Append("b", "c", "r")
+	if err := l.Commit(first); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := l.Commit(first); err != nil {
+		t.Fatalf("Commit() of a committed record error = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":2,"op":"add","from":"b","to":"c","relation":"r"}` + "\n"
+	if string(data) != want {
+		t.Errorf("compacted log = %q, want %q", data, want)
+	}
+
+	l, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer l.Close()
+	pending := l.Pending()
+	if len(pending) != 1 || pending[0].ID != second {
+		t.Errorf("Pending() after reopening = %+v, want only record %d", pending, second)
+	}
+}
+
+func TestAppendIsDurableBeforeClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relations.wal")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer l.Close()
+	if _, err := l.Append("a", "b", "r"); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path) // As a crash would leave it
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"op":"add","from":"a","to":"b"`) {
+		t.Errorf("log before Close() = %q, want the appended record", data)
+	}
+}